@@ -3,17 +3,24 @@ package webutil
 import (
 	"bytes"
 	"encoding/json"
+	"log"
 	"net/http"
 
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"github.com/pkg/errors"
 )
 
 const (
 	bodyRequiredTxt = "Request must have body"
 	invalidJSONTxt  = "Invalid json"
 	serverErrTxt    = "Server error, please try again later"
+
+	bodyTooLargeTxt           = "Request body too large"
+	unsupportedContentTypeTxt = "Unsupported content type"
+	invalidMultipartTxt       = "Invalid multipart form"
 )
 
 //////////////////////////////////////////////////////////////////
@@ -30,10 +37,159 @@ type HTTPResponseConfig struct {
 	HTTPResponse []byte
 }
 
+// ErrorWriterConfig is config struct used in the initialization
+// of *ErrorWriter
+type ErrorWriterConfig struct {
+	// ProblemJSON determines whether errors are written as
+	// RFC 7807 "application/problem+json" responses
+	ProblemJSON bool
+
+	// Logger is called with errors that result in a 500 response
+	// so the real error is recorded but not sent to the client
+	//
+	// Default: log.Printf
+	Logger func(err error)
+
+	// Catalog is used by ErrorWriter#WriteRequestError to translate
+	// validation errors to the locale of the request
+	//
+	// Default: DefaultCatalog
+	Catalog *Catalog
+}
+
+// ErrorWriter writes errors returned from decoding, validation and
+// query building as http responses with a consistent status and body
+type ErrorWriter struct {
+	config ErrorWriterConfig
+}
+
+// ProblemDetails is the RFC 7807 body written by ErrorWriter
+// when ProblemJSON is set
+type ProblemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+
+	// Errors is set to field errors for validation.Errors
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	// DefaultErrorWriter is the *ErrorWriter used by WriteError
+	DefaultErrorWriter = NewErrorWriter(ErrorWriterConfig{})
+)
+
 //////////////////////////////////////////////////////////////////
 //------------------------- FUNCTIONS --------------------------
 //////////////////////////////////////////////////////////////////
 
+// NewErrorWriter returns *ErrorWriter instance
+func NewErrorWriter(config ErrorWriterConfig) *ErrorWriter {
+	return &ErrorWriter{config: config}
+}
+
+// WriteError writes err to w using DefaultErrorWriter
+func WriteError(w http.ResponseWriter, err error) {
+	DefaultErrorWriter.WriteError(w, err)
+}
+
+// WriteRequestError writes err to w using DefaultErrorWriter
+func WriteRequestError(w http.ResponseWriter, req *http.Request, err error) {
+	DefaultErrorWriter.WriteRequestError(w, req, err)
+}
+
+// WriteRequestError is the same as WriteError except validation
+// errors are translated to the locale returned by RequestLocale
+func (e *ErrorWriter) WriteRequestError(w http.ResponseWriter, req *http.Request, err error) {
+	e.WriteError(w, LocalizeError(err, e.config.Catalog, RequestLocale(req, e.config.Catalog)))
+}
+
+// WriteError maps err to a status and writes it to w
+//
+// ErrBodyRequired, ErrInvalidJSON, ErrInvalidMultipart, *DecodeError and
+// QueryBuilderError write 400, ErrBodyTooLarge writes 413, ErrUnsupportedContentType writes 415,
+// validation.Errors writes 422 with a nested field to message body,
+// and validation.InternalError along with any other error writes 500
+// after logging the real error
+//
+// Nothing is written if err is nil
+func (e *ErrorWriter) WriteError(w http.ResponseWriter, err error) {
+	var status int
+	var detail string
+	var valErrs validation.Errors
+	var queryErr QueryBuilderError
+	var decodeErr *DecodeError
+
+	if err == nil {
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrBodyRequired):
+		status, detail = http.StatusBadRequest, bodyRequiredTxt
+	case errors.Is(err, ErrBodyTooLarge):
+		status, detail = http.StatusRequestEntityTooLarge, bodyTooLargeTxt
+	case errors.Is(err, ErrUnsupportedContentType):
+		status, detail = http.StatusUnsupportedMediaType, unsupportedContentTypeTxt
+	case errors.As(err, &decodeErr):
+		status, detail = http.StatusBadRequest, decodeErr.Error()
+	case errors.Is(err, ErrInvalidJSON):
+		status, detail = http.StatusBadRequest, invalidJSONTxt
+	case errors.Is(err, ErrInvalidMultipart):
+		status, detail = http.StatusBadRequest, invalidMultipartTxt
+	case errors.As(err, &queryErr):
+		status, detail = http.StatusBadRequest, queryErr.Error()
+	case errors.As(err, &valErrs):
+		status = http.StatusUnprocessableEntity
+	default:
+		// validation.InternalError and unknown errors should not
+		// be shown to client
+		status, detail = http.StatusInternalServerError, serverErrTxt
+
+		if e.config.Logger != nil {
+			e.config.Logger(err)
+		} else {
+			log.Printf("webutil: %+v", err)
+		}
+	}
+
+	var body []byte
+	var marshalErr error
+
+	if e.config.ProblemJSON {
+		w.Header().Set("Content-Type", PROBLEM_JSON_CONTENT_HEADER)
+		body, marshalErr = json.Marshal(ProblemDetails{
+			Type:   "about:blank",
+			Title:  http.StatusText(status),
+			Status: status,
+			Detail: detail,
+			Errors: valErrs,
+		})
+	} else {
+		w.Header().Set("Content-Type", JSON_CONTENT_HEADER)
+
+		if valErrs != nil {
+			body, marshalErr = json.Marshal(valErrs)
+		} else {
+			body, marshalErr = json.Marshal(map[string]string{"error": detail})
+		}
+	}
+
+	if marshalErr != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(serverErrTxt))
+		return
+	}
+
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
 // SetToken is wrapper function for setting csrf token header
 func SetToken(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(TOKEN_HEADER, csrf.Token(r))
@@ -1,8 +1,15 @@
 package webutil
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	pkgerrors "github.com/pkg/errors"
 )
 
 // const (
@@ -50,3 +57,71 @@ func TestSendPayloadUnitTest(t *testing.T) {
 		t.Fatalf("should have error\n")
 	}
 }
+
+func TestWriteErrorUnitTest(t *testing.T) {
+	var logged error
+
+	ew := NewErrorWriter(ErrorWriterConfig{
+		Logger: func(err error) {
+			logged = err
+		},
+	})
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{"body required", ErrBodyRequired, http.StatusBadRequest, `{"error":"Request must have body"}`},
+		{"invalid json", fmt.Errorf("%w: %w", io.EOF, ErrInvalidJSON), http.StatusBadRequest, `{"error":"Invalid json"}`},
+		{"query builder", pkgerrors.WithStack(QueryBuilderError{errorMsg: "invalid limit"}), http.StatusBadRequest, `{"error":"invalid limit"}`},
+		{
+			"validation",
+			validation.Errors{"name": errors.New(REQUIRED_TXT), "address": validation.Errors{"zip": errors.New(INVALID_TXT)}},
+			http.StatusUnprocessableEntity,
+			`{"address":{"zip":"invalid"},"name":"required"}`,
+		},
+		{"internal", validation.NewInternalError(errors.New("db down")), http.StatusInternalServerError, `{"error":"Server error, please try again later"}`},
+	}
+
+	for _, test := range tests {
+		rr := httptest.NewRecorder()
+		ew.WriteError(rr, test.err)
+
+		if rr.Code != test.status {
+			t.Errorf("%s: status should be %d; got %d\n", test.name, test.status, rr.Code)
+		}
+		if rr.Body.String() != test.body {
+			t.Errorf("%s: body should be %s; got %s\n", test.name, test.body, rr.Body.String())
+		}
+	}
+
+	if logged == nil || logged.Error() != "db down" {
+		t.Errorf("internal error should have been logged; got %v\n", logged)
+	}
+
+	logged = nil
+	rr := httptest.NewRecorder()
+	ew.WriteError(rr, nil)
+
+	if rr.Body.Len() != 0 || logged != nil {
+		t.Errorf("nil error should not be written or logged; got %s\n", rr.Body.String())
+	}
+
+	// ---------------------------------------------------------------------
+
+	ew = NewErrorWriter(ErrorWriterConfig{ProblemJSON: true})
+	rr = httptest.NewRecorder()
+	ew.WriteError(rr, validation.Errors{"name": errors.New(REQUIRED_TXT)})
+
+	if rr.Header().Get("Content-Type") != PROBLEM_JSON_CONTENT_HEADER {
+		t.Errorf("content type should be %s; got %s\n", PROBLEM_JSON_CONTENT_HEADER, rr.Header().Get("Content-Type"))
+	}
+
+	expected := `{"type":"about:blank","title":"Unprocessable Entity","status":422,"errors":{"name":"required"}}`
+
+	if rr.Body.String() != expected {
+		t.Errorf("body should be %s; got %s\n", expected, rr.Body.String())
+	}
+}
@@ -0,0 +1,269 @@
+package webutil
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/go-jet/jet/v2/qrm"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// validationBatch records database validator calls while collecting
+// and hands back query results in the same order while resolving
+type validationBatch struct {
+	mu         sync.Mutex
+	collecting bool
+	calls      []*batchCall
+	results    map[*validator][]*batchCall
+}
+
+type batchCall struct {
+	validator    *validator
+	cached       bool
+	validateType int
+	query        string
+	args         []any
+	expectedLen  int
+	counter      int
+	err          error
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// BatchValidateStruct validates structPtr the same as validation.ValidateStruct
+// except every ValidateArgs, ValidateExists and ValidateUniqueness rule created
+// from the *FormValidation passed to fieldsFn is run as a single batch
+//
+// The fields are validated twice; the first pass collects the query of each
+// database rule without running it, the collected queries are then deduplicated
+// and run concurrently as "SELECT COUNT(*)" or "SELECT EXISTS" queries, and the
+// second pass maps each result back to the field that needs it
+//
+// Rules that don't query the database, including custom rules, are run in
+// both passes as validation.FieldRules can't be filtered, so they should not
+// have side effects, where errors of the first pass are discarded
+//
+// If the context of f has locale set by WithLocale, errors are
+// translated through FormValidationConfig#Catalog, e.g.
+//
+//	err := formValidation.BatchValidateStruct(&form, func(fv *FormValidation) []*validation.FieldRules {
+//		return []*validation.FieldRules{
+//			validation.Field(&form.UserID, fv.ValidateExists(0, "SELECT id FROM user WHERE id = ?")),
+//			validation.Field(&form.TagIDs, fv.ValidateArgs(0, "SELECT id FROM tag WHERE id IN (?)")),
+//		}
+//	})
+func (f *FormValidation) BatchValidateStruct(
+	structPtr any,
+	fieldsFn func(fv *FormValidation) []*validation.FieldRules,
+) error {
+	batch := &validationBatch{
+		collecting: true,
+		results:    make(map[*validator][]*batchCall),
+	}
+
+	fv := new(FormValidation)
+	*fv = *f
+	fv.batch = batch
+
+	fields := fieldsFn(fv)
+
+	if err := validation.ValidateStruct(structPtr, fields...); err != nil {
+		if internalErr, ok := err.(validation.InternalError); ok && internalErr.InternalError() != nil {
+			return err
+		}
+	}
+
+	batch.run(fv.Context(), fv)
+
+	batch.mu.Lock()
+	batch.collecting = false
+	batch.mu.Unlock()
+
+	err := validation.ValidateStruct(structPtr, fields...)
+
+	if locale, ok := LocaleFromContext(fv.Context()); ok {
+		return LocalizeError(err, f.config.Catalog, locale)
+	}
+
+	return err
+}
+
+// isCollecting returns whether batch is recording queries
+func (b *validationBatch) isCollecting() bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.collecting
+}
+
+// validate either records query while batch is collecting or returns
+// result of query that was recorded for validator
+func (b *validationBatch) validate(v *validator, validateType int, query string, args []any, expectedLen int) error {
+	b.mu.Lock()
+
+	if b.collecting {
+		call := &batchCall{
+			validator:    v,
+			validateType: validateType,
+			query:        query,
+			args:         args,
+			expectedLen:  expectedLen,
+		}
+		call.counter, call.cached = v.cacheGet(validateType, query, args)
+
+		b.calls = append(b.calls, call)
+		b.results[v] = append(b.results[v], call)
+		b.mu.Unlock()
+		return nil
+	}
+
+	calls := b.results[v]
+
+	if len(calls) == 0 {
+		b.mu.Unlock()
+
+		// Validator was not reached while collecting so run it by
+		// itself, still as count query so rows aren't iterated
+		if counter, ok := v.cacheGet(validateType, query, args); ok {
+			return validatorResult(v, validateType, counter, expectedLen)
+		}
+
+		ctx := v.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+
+		counter, err := batchQueryCount(ctx, v.queryable, batchCountQuery(validateType, query), args)
+		if err != nil {
+			msg := fmt.Errorf("err: %w\n query:%s\n args:%v\n", err, query, args)
+			return errors.WithStack(validation.NewInternalError(msg))
+		}
+
+		v.cacheSet(validateType, query, args, counter)
+		return validatorResult(v, validateType, counter, expectedLen)
+	}
+
+	call := calls[0]
+	b.results[v] = calls[1:]
+	b.mu.Unlock()
+
+	if call.err != nil {
+		msg := fmt.Errorf("err: %w\n query:%s\n args:%v\n", call.err, call.query, call.args)
+		return errors.WithStack(validation.NewInternalError(msg))
+	}
+
+	return validatorResult(v, validateType, call.counter, call.expectedLen)
+}
+
+// run executes every collected call, only querying identical
+// queries once
+//
+// Query errors are stored on each call so they are returned
+// as internal errors for the field that needs the result
+func (b *validationBatch) run(ctx context.Context, f *FormValidation) {
+	type batchQuery struct {
+		query string
+		args  []any
+		calls []*batchCall
+	}
+
+	queryMap := make(map[string]*batchQuery)
+	queries := make([]*batchQuery, 0)
+
+	for _, call := range b.calls {
+		if call.cached {
+			continue
+		}
+
+		query := batchCountQuery(call.validateType, call.query)
+		key := fmt.Sprintf("%s|%#v", query, call.args)
+
+		bq, ok := queryMap[key]
+		if !ok {
+			bq = &batchQuery{query: query, args: call.args}
+			queryMap[key] = bq
+			queries = append(queries, bq)
+		}
+
+		bq.calls = append(bq.calls, call)
+	}
+
+	concurrency := f.config.BatchConcurrency
+	if concurrency < 1 {
+		concurrency = DEFAULT_BATCH_CONCURRENCY
+	}
+	if _, ok := f.queryable.(*sql.DB); !ok {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for _, bq := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(bq *batchQuery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			counter, err := batchQueryCount(ctx, f.queryable, bq.query, bq.args)
+
+			for _, call := range bq.calls {
+				call.counter = counter
+				call.err = err
+
+				if err == nil {
+					call.validator.cacheSet(call.validateType, call.query, call.args, counter)
+				}
+			}
+		}(bq)
+	}
+
+	wg.Wait()
+}
+
+// batchQueryCount runs count query and returns the single
+// number it selects
+func batchQueryCount(ctx context.Context, queryable qrm.Queryable, query string, args []any) (int, error) {
+	rows, err := queryable.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var counter int64
+
+	if rows.Next() {
+		if err = rows.Scan(&counter); err != nil {
+			return 0, err
+		}
+	}
+
+	return int(counter), rows.Err()
+}
+
+// batchCountQuery wraps query so database returns number of rows
+// instead of the rows themselves
+func batchCountQuery(validateType int, query string) string {
+	if validateType == validateArgsType {
+		return "SELECT COUNT(*) FROM (" + query + ") AS webutil_batch"
+	}
+
+	return "SELECT CASE WHEN EXISTS (" + query + ") THEN 1 ELSE 0 END"
+}
@@ -0,0 +1,189 @@
+package webutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// batchTestQueryable wraps *sql.DB like a transaction helper would
+// and records max number of queries run at the same time
+type batchTestQueryable struct {
+	db        *sql.DB
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (b *batchTestQueryable) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	b.mu.Lock()
+	b.active++
+	b.maxActive = max(b.maxActive, b.active)
+	b.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+	rows, err := b.db.QueryContext(ctx, query, args...)
+
+	b.mu.Lock()
+	b.active--
+	b.mu.Unlock()
+
+	return rows, err
+}
+
+func TestBatchValidateStructUnitTest(t *testing.T) {
+	type form struct {
+		UserID    int    `json:"userID"`
+		CreatorID int    `json:"creatorID"`
+		TagIDs    []int  `json:"tagIDs"`
+		Email     string `json:"email"`
+		Name      string `json:"name"`
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	mock.MatchExpectationsInOrder(false)
+
+	fv := NewFormValidation(db, FormValidationConfig{SQLBindVar: DOLLAR_SQL_BIND_VAR})
+
+	f := form{
+		UserID:    1,
+		CreatorID: 1,
+		TagIDs:    []int{1, 2, 3},
+		Email:     "foo@example.com",
+	}
+
+	// UserID and CreatorID share the same query so should only be run once
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN EXISTS (SELECT id FROM user WHERE id = $1) THEN 1 ELSE 0 END")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM (SELECT id FROM tag WHERE id IN ($1, $2, $3)) AS webutil_batch")).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN EXISTS (SELECT email FROM user WHERE email = $1) THEN 1 ELSE 0 END")).
+		WithArgs(f.Email).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+
+	err = fv.BatchValidateStruct(&f, func(fv *FormValidation) []*validation.FieldRules {
+		return []*validation.FieldRules{
+			validation.Field(&f.UserID, fv.ValidateExists(0, "SELECT id FROM user WHERE id = ?")),
+			validation.Field(&f.CreatorID, fv.ValidateExists(0, "SELECT id FROM user WHERE id = ?")),
+			validation.Field(&f.TagIDs, fv.ValidateArgs(0, "SELECT id FROM tag WHERE id IN (?)")),
+			validation.Field(&f.Email, fv.ValidateUniqueness(nil, 0, "SELECT email FROM user WHERE email = ?")),
+			validation.Field(&f.Name, RequiredRule),
+		}
+	})
+
+	valErrs, ok := err.(validation.Errors)
+	if !ok {
+		t.Fatalf("should have validation.Errors; got %v\n", err)
+	}
+
+	expected := map[string]string{
+		"tagIDs": INVALID_TXT,
+		"email":  ALREADY_EXISTS_TXT,
+		"name":   REQUIRED_TXT,
+	}
+
+	if len(valErrs) != len(expected) {
+		t.Errorf("should have %d errors; got %v\n", len(expected), valErrs)
+	}
+
+	for k, v := range expected {
+		if valErrs[k] == nil || valErrs[k].Error() != v {
+			t.Errorf("field %q should have error %q; got %v\n", k, v, valErrs[k])
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------
+
+	// Validator only reached in second pass is still run as count query
+	calls := 0
+	firstPassErr := validation.By(func(value any) error {
+		if calls++; calls == 1 {
+			return errors.New("first pass")
+		}
+
+		return nil
+	})
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN EXISTS (SELECT id FROM role WHERE id = $1) THEN 1 ELSE 0 END")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+
+	err = fv.BatchValidateStruct(&f, func(fv *FormValidation) []*validation.FieldRules {
+		return []*validation.FieldRules{
+			validation.Field(&f.UserID, firstPassErr, fv.ValidateExists(0, "SELECT id FROM role WHERE id = ?")),
+		}
+	})
+	if err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
+
+func TestBatchValidateStructSequentialUnitTest(t *testing.T) {
+	type form struct {
+		UserID int `json:"userID"`
+		TagID  int `json:"tagID"`
+		RoleID int `json:"roleID"`
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	mock.MatchExpectationsInOrder(false)
+
+	queryable := &batchTestQueryable{db: db}
+	fv := NewFormValidation(queryable, FormValidationConfig{
+		SQLBindVar:       DOLLAR_SQL_BIND_VAR,
+		BatchConcurrency: 4,
+	})
+
+	f := form{UserID: 1, TagID: 2, RoleID: 3}
+
+	for _, table := range []string{"user", "tag", "role"} {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN EXISTS (SELECT id FROM " + table + " WHERE id = $1) THEN 1 ELSE 0 END")).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	}
+
+	err = fv.BatchValidateStruct(&f, func(fv *FormValidation) []*validation.FieldRules {
+		return []*validation.FieldRules{
+			validation.Field(&f.UserID, fv.ValidateExists(0, "SELECT id FROM user WHERE id = ?")),
+			validation.Field(&f.TagID, fv.ValidateExists(0, "SELECT id FROM tag WHERE id = ?")),
+			validation.Field(&f.RoleID, fv.ValidateExists(0, "SELECT id FROM role WHERE id = ?")),
+		}
+	})
+	if err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if queryable.maxActive != 1 {
+		t.Errorf("should run queries one at a time; got %d at once\n", queryable.maxActive)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
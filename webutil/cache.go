@@ -0,0 +1,253 @@
+package webutil
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+//////////////////////////////////////////////////////////////////
+//----------------------- INTERFACES -------------------------
+//////////////////////////////////////////////////////////////////
+
+// ValidationCache is used by database validators that have called
+// Cache() to store the number of rows found for a query so lookups
+// against reference tables don't hit the database on every request
+type ValidationCache interface {
+	// Get returns number of rows stored for key
+	Get(key string) (int, bool)
+
+	// Set stores number of rows for key along with tags
+	// that can be used to invalidate key
+	Set(key string, counter int, tags []string)
+
+	// Invalidate removes every key stored with any of tags
+	Invalidate(tags ...string)
+
+	// Clear removes every key
+	Clear()
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// MemoryValidationCacheConfig is config struct used in the initialization
+// of *MemoryValidationCache
+type MemoryValidationCacheConfig struct {
+	// TTL is how long a key is valid for
+	//
+	// Default: 0 (never expires)
+	TTL time.Duration
+
+	// MaxEntries is max number of keys stored before the least
+	// recently used key is evicted
+	//
+	// Default: 0 (no limit)
+	MaxEntries int
+
+	// OnEvict is called with key whenever a key is removed due to
+	// expiring, being least recently used or invalidated
+	//
+	// OnEvict is called after the cache is unlocked so it's safe
+	// to use the cache within OnEvict
+	OnEvict func(key string)
+}
+
+// MemoryValidationCache is in memory ValidationCache with ttl
+// and lru eviction
+type MemoryValidationCache struct {
+	mu      sync.Mutex
+	config  MemoryValidationCacheConfig
+	ll      *list.List
+	items   map[string]*list.Element
+	tagKeys map[string]map[string]struct{}
+	evicted []string
+	now     func() time.Time
+}
+
+type memoryCacheEntry struct {
+	key       string
+	counter   int
+	tags      []string
+	expiresAt time.Time
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewMemoryValidationCache returns *MemoryValidationCache instance
+func NewMemoryValidationCache(config MemoryValidationCacheConfig) *MemoryValidationCache {
+	return &MemoryValidationCache{
+		config:  config,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		tagKeys: make(map[string]map[string]struct{}),
+		now:     time.Now,
+	}
+}
+
+// validationCacheKey returns key used to store result of query
+func validationCacheKey(validateType int, query string, args []any) string {
+	values := make([]any, 0, len(args))
+
+	for _, arg := range args {
+		values = append(values, validationCacheValue(arg))
+	}
+
+	return fmt.Sprintf("%d|%s|%#v", validateType, query, values)
+}
+
+// validationCacheValue returns driver value of arg so pointers are
+// keyed by what they point to rather than their address
+func validationCacheValue(arg any) any {
+	value, isNil := validation.Indirect(arg)
+	if isNil {
+		return nil
+	}
+
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]any, 0, rv.Len())
+
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, validationCacheValue(rv.Index(i).Interface()))
+		}
+
+		return values
+	}
+
+	if converted, err := driver.DefaultParameterConverter.ConvertValue(arg); err == nil {
+		return converted
+	}
+
+	return value
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Get returns number of rows stored for key if key has not expired
+func (m *MemoryValidationCache) Get(key string) (int, bool) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	elem, ok := m.items[key]
+	if !ok {
+		return 0, false
+	}
+
+	entry := elem.Value.(*memoryCacheEntry)
+
+	if !entry.expiresAt.IsZero() && m.now().After(entry.expiresAt) {
+		m.removeElement(elem)
+		return 0, false
+	}
+
+	m.ll.MoveToFront(elem)
+	return entry.counter, true
+}
+
+// Set stores number of rows for key, evicting least recently
+// used key if MaxEntries is exceeded
+func (m *MemoryValidationCache) Set(key string, counter int, tags []string) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	if elem, ok := m.items[key]; ok {
+		m.removeElement(elem)
+	}
+
+	entry := &memoryCacheEntry{
+		key:     key,
+		counter: counter,
+		tags:    tags,
+	}
+
+	if m.config.TTL > 0 {
+		entry.expiresAt = m.now().Add(m.config.TTL)
+	}
+
+	m.items[key] = m.ll.PushFront(entry)
+
+	for _, tag := range tags {
+		if _, ok := m.tagKeys[tag]; !ok {
+			m.tagKeys[tag] = make(map[string]struct{})
+		}
+
+		m.tagKeys[tag][key] = struct{}{}
+	}
+
+	if m.config.MaxEntries > 0 && m.ll.Len() > m.config.MaxEntries {
+		m.removeElement(m.ll.Back())
+	}
+}
+
+// Invalidate removes every key stored with any of tags
+func (m *MemoryValidationCache) Invalidate(tags ...string) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	for _, tag := range tags {
+		for key := range m.tagKeys[tag] {
+			if elem, ok := m.items[key]; ok {
+				m.removeElement(elem)
+			}
+		}
+	}
+}
+
+// Clear removes every key
+func (m *MemoryValidationCache) Clear() {
+	m.mu.Lock()
+	defer m.unlock()
+
+	for m.ll.Len() > 0 {
+		m.removeElement(m.ll.Back())
+	}
+}
+
+// Len returns number of keys currently stored
+func (m *MemoryValidationCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.ll.Len()
+}
+
+func (m *MemoryValidationCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*memoryCacheEntry)
+
+	m.ll.Remove(elem)
+	delete(m.items, entry.key)
+
+	for _, tag := range entry.tags {
+		delete(m.tagKeys[tag], entry.key)
+
+		if len(m.tagKeys[tag]) == 0 {
+			delete(m.tagKeys, tag)
+		}
+	}
+
+	if m.config.OnEvict != nil {
+		m.evicted = append(m.evicted, entry.key)
+	}
+}
+
+// unlock unlocks m.mu and then calls OnEvict with keys removed
+// while locked so OnEvict can't deadlock by using the cache
+func (m *MemoryValidationCache) unlock() {
+	evicted := m.evicted
+	m.evicted = nil
+	m.mu.Unlock()
+
+	for _, key := range evicted {
+		m.config.OnEvict(key)
+	}
+}
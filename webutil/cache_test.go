@@ -0,0 +1,166 @@
+package webutil
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMemoryValidationCacheUnitTest(t *testing.T) {
+	var cache *MemoryValidationCache
+
+	evicted := make([]string, 0)
+	now := time.Now()
+
+	// Using cache within OnEvict shouldn't deadlock
+	cache = NewMemoryValidationCache(MemoryValidationCacheConfig{
+		TTL:        time.Minute,
+		MaxEntries: 2,
+		OnEvict: func(key string) {
+			cache.Len()
+			evicted = append(evicted, key)
+		},
+	})
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", 1, []string{"country"})
+	cache.Set("b", 2, []string{"status"})
+
+	if counter, ok := cache.Get("a"); !ok || counter != 1 {
+		t.Errorf("key 'a' should be 1; got %d\n", counter)
+	}
+
+	// "b" is least recently used so should be evicted
+	cache.Set("c", 3, []string{"country"})
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("key 'b' should have been evicted\n")
+	}
+
+	cache.Invalidate("country")
+
+	if cache.Len() != 0 {
+		t.Errorf("cache should be empty; got %d keys\n", cache.Len())
+	}
+
+	if len(evicted) != 3 {
+		t.Errorf("should have evicted 3 keys; got %v\n", evicted)
+	}
+
+	cache.Set("d", 4, nil)
+	now = now.Add(time.Minute * 2)
+
+	if _, ok := cache.Get("d"); ok {
+		t.Errorf("key 'd' should have expired\n")
+	}
+
+	cache.Set("e", 5, nil)
+	cache.Clear()
+
+	if cache.Len() != 0 {
+		t.Errorf("cache should be empty; got %d keys\n", cache.Len())
+	}
+}
+
+func TestValidateExistsCacheUnitTest(t *testing.T) {
+	var err error
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	cache := NewMemoryValidationCache(MemoryValidationCacheConfig{})
+	fv := NewFormValidation(db, FormValidationConfig{
+		SQLBindVar: DOLLAR_SQL_BIND_VAR,
+		Cache:      cache,
+	})
+
+	query := "SELECT id FROM country WHERE id = ?"
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM country WHERE id = $1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	for i := 0; i < 2; i++ {
+		if err = fv.ValidateExists(0, query).Cache("country").Validate(1); err != nil {
+			t.Errorf("should not have error; got %s\n", err.Error())
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("second validate should have used cache: %s\n", err.Error())
+	}
+
+	cache.Invalidate("country")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM country WHERE id = $1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if err = fv.ValidateExists(0, query).Cache("country").Validate(1); err == nil {
+		t.Errorf("should have error\n")
+	} else if err.Error() != DOES_NOT_EXIST_TXT {
+		t.Errorf("should have %q error; got %s\n", DOES_NOT_EXIST_TXT, err.Error())
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
+
+func TestValidateExistsCachePointerUnitTest(t *testing.T) {
+	var err error
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	cache := NewMemoryValidationCache(MemoryValidationCacheConfig{})
+	fv := NewFormValidation(db, FormValidationConfig{
+		SQLBindVar: DOLLAR_SQL_BIND_VAR,
+		Cache:      cache,
+	})
+
+	query := "SELECT id FROM country WHERE id = ?"
+	first, second, third := "1", "2", "1"
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM country WHERE id = $1")).
+		WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM country WHERE id = $1")).
+		WithArgs("2").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if err = fv.ValidateExists(0, query).Cache("country").Validate(&first); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if err = fv.ValidateExists(0, query).Cache("country").Validate(&second); err == nil {
+		t.Errorf("should have error for different value\n")
+	}
+
+	// Same value at a different address should use cache
+	if err = fv.ValidateExists(0, query).Cache("country").Validate(&third); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if len(cache.items) != 2 {
+		t.Errorf("should have 2 cache entries; got %d\n", len(cache.items))
+	}
+
+	if validationCacheKey(0, query, []any{&first}) != validationCacheKey(0, query, []any{"1"}) {
+		t.Errorf("should have same key for pointer and value\n")
+	}
+	if validationCacheKey(0, query, []any{[]*string{&first}}) == validationCacheKey(0, query, []any{[]*string{&second}}) {
+		t.Errorf("should have different keys for slices of different values\n")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
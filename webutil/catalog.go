@@ -0,0 +1,375 @@
+package webutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// MessageError is error returned from built in rules which is rendered
+// through a *Catalog so the message can be translated
+//
+// Error returns the message of DEFAULT_LOCALE from DefaultCatalog
+type MessageError struct {
+	// Code is key of message within catalog, e.g. REQUIRED_CODE
+	Code string
+
+	// Params replace "{key}" placeholders within message,
+	// e.g. {"min": 5} for "Can't be less than {min}"
+	Params map[string]any
+}
+
+// Catalog stores messages by locale and error code
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+type localeKey struct{}
+
+// messageRule wraps rule so any validation error it returns
+// is replaced with *MessageError of code and params
+type messageRule struct {
+	rule   validation.Rule
+	code   string
+	params map[string]any
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	// DefaultCatalog is catalog used by *MessageError#Error and when
+	// no catalog is given, which contains the built in messages for
+	// english, spanish and french
+	DefaultCatalog = NewCatalog()
+
+	builtinMessages = map[string]map[string]string{
+		"en": {
+			REQUIRED_CODE:               REQUIRED_TXT,
+			ALREADY_EXISTS_CODE:         ALREADY_EXISTS_TXT,
+			DOES_NOT_EXIST_CODE:         DOES_NOT_EXIST_TXT,
+			INVALID_CODE:                INVALID_TXT,
+			INVALID_FORMAT_CODE:         INVALID_FORMAT_TXT,
+			INVALID_FUTURE_DATE_CODE:    INVALID_FUTURE_DATE_TXT,
+			INVALID_PAST_DATE_CODE:      INVALID_PAST_DATE_TXT,
+			DATE_BEFORE_MIN_CODE:        DATE_BEFORE_MIN_TXT,
+			DATE_AFTER_MAX_CODE:         DATE_AFTER_MAX_TXT,
+			CANT_BE_NEGATIVE_CODE:       CANT_BE_NEGATIVE_TXT,
+			MIN_VALUE_CODE:              MIN_VALUE_TXT,
+			MAX_VALUE_CODE:              MAX_VALUE_TXT,
+			MIN_LENGTH_CODE:             MIN_LENGTH_TXT,
+			MAX_LENGTH_CODE:             MAX_LENGTH_TXT,
+			INVALID_CURRENCY_CODE:       INVALID_CURRENCY_TXT,
+			INVALID_DECIMAL_PLACES_CODE: INVALID_DECIMAL_PLACES_TXT,
+			FIELDS_DONT_MATCH_CODE:      FIELDS_DONT_MATCH_TXT,
+			INVALID_DATE_RANGE_CODE:     INVALID_DATE_RANGE_TXT,
+			MUTUALLY_EXCLUSIVE_CODE:     MUTUALLY_EXCLUSIVE_TXT,
+			INVALID_FILE_COUNT_CODE:     INVALID_FILE_COUNT_TXT,
+			FILE_TOO_LARGE_CODE:         FILE_TOO_LARGE_TXT,
+			INVALID_FILE_TYPE_CODE:      INVALID_FILE_TYPE_TXT,
+			INVALID_FILE_EXTENSION_CODE: INVALID_FILE_EXTENSION_TXT,
+		},
+		"es": {
+			REQUIRED_CODE:               "requerido",
+			ALREADY_EXISTS_CODE:         "ya existe",
+			DOES_NOT_EXIST_CODE:         "no existe",
+			INVALID_CODE:                "inválido",
+			INVALID_FORMAT_CODE:         "formato inválido",
+			INVALID_FUTURE_DATE_CODE:    "la fecha no puede ser posterior a la fecha/hora actual",
+			INVALID_PAST_DATE_CODE:      "la fecha no puede ser anterior a la fecha/hora actual",
+			DATE_BEFORE_MIN_CODE:        "la fecha no puede ser anterior a {min}",
+			DATE_AFTER_MAX_CODE:         "la fecha no puede ser posterior a {max}",
+			CANT_BE_NEGATIVE_CODE:       "no puede ser negativo",
+			MIN_VALUE_CODE:              "No puede ser menor que {min}",
+			MAX_VALUE_CODE:              "No puede ser mayor que {max}",
+			MIN_LENGTH_CODE:             "la longitud no puede ser menor que {min}",
+			MAX_LENGTH_CODE:             "la longitud no puede ser mayor que {max}",
+			INVALID_CURRENCY_CODE:       "moneda inválida",
+			INVALID_DECIMAL_PLACES_CODE: "no puede tener más de {places} decimales",
+			FIELDS_DONT_MATCH_CODE:      "no coincide",
+			INVALID_DATE_RANGE_CODE:     "la fecha debe ser posterior a la fecha de inicio",
+			MUTUALLY_EXCLUSIVE_CODE:     "no se puede establecer junto con otros campos",
+			INVALID_FILE_COUNT_CODE:     "número de archivos inválido",
+			FILE_TOO_LARGE_CODE:         "archivo demasiado grande",
+			INVALID_FILE_TYPE_CODE:      "tipo de archivo inválido",
+			INVALID_FILE_EXTENSION_CODE: "extensión de archivo inválida",
+		},
+		"fr": {
+			REQUIRED_CODE:               "obligatoire",
+			ALREADY_EXISTS_CODE:         "existe déjà",
+			DOES_NOT_EXIST_CODE:         "n'existe pas",
+			INVALID_CODE:                "invalide",
+			INVALID_FORMAT_CODE:         "format invalide",
+			INVALID_FUTURE_DATE_CODE:    "la date ne peut pas être postérieure à la date/heure actuelle",
+			INVALID_PAST_DATE_CODE:      "la date ne peut pas être antérieure à la date/heure actuelle",
+			DATE_BEFORE_MIN_CODE:        "la date ne peut pas être antérieure au {min}",
+			DATE_AFTER_MAX_CODE:         "la date ne peut pas être postérieure au {max}",
+			CANT_BE_NEGATIVE_CODE:       "ne peut pas être négatif",
+			MIN_VALUE_CODE:              "Ne peut pas être inférieur à {min}",
+			MAX_VALUE_CODE:              "Ne peut pas être supérieur à {max}",
+			MIN_LENGTH_CODE:             "la longueur ne peut pas être inférieure à {min}",
+			MAX_LENGTH_CODE:             "la longueur ne peut pas être supérieure à {max}",
+			INVALID_CURRENCY_CODE:       "devise invalide",
+			INVALID_DECIMAL_PLACES_CODE: "ne peut pas avoir plus de {places} décimales",
+			FIELDS_DONT_MATCH_CODE:      "ne correspond pas",
+			INVALID_DATE_RANGE_CODE:     "la date doit être postérieure à la date de début",
+			MUTUALLY_EXCLUSIVE_CODE:     "ne peut pas être défini avec d'autres champs",
+			INVALID_FILE_COUNT_CODE:     "nombre de fichiers invalide",
+			FILE_TOO_LARGE_CODE:         "fichier trop volumineux",
+			INVALID_FILE_TYPE_CODE:      "type de fichier invalide",
+			INVALID_FILE_EXTENSION_CODE: "extension de fichier invalide",
+		},
+	}
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewMessageError returns *MessageError instance
+func NewMessageError(code string, params map[string]any) *MessageError {
+	return &MessageError{Code: code, Params: params}
+}
+
+// NewCatalog returns *Catalog instance loaded with the built in messages
+func NewCatalog() *Catalog {
+	c := &Catalog{messages: make(map[string]map[string]string)}
+
+	for locale, messages := range builtinMessages {
+		c.Set(locale, messages)
+	}
+
+	return c
+}
+
+// WithLocale returns copy of ctx that carries locale, which takes
+// precedence over "Accept-Language" header in RequestLocale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFromContext returns locale set by WithLocale
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok && locale != ""
+}
+
+// RequestLocale returns locale of req from its context if set by WithLocale,
+// else best match of "Accept-Language" header within catalog, else DEFAULT_LOCALE
+//
+// If catalog is nil, DefaultCatalog is used
+func RequestLocale(req *http.Request, catalog *Catalog) string {
+	if locale, ok := LocaleFromContext(req.Context()); ok {
+		return locale
+	}
+
+	if catalog == nil {
+		catalog = DefaultCatalog
+	}
+
+	if locale := catalog.MatchLocale(req.Header.Get("Accept-Language")); locale != "" {
+		return locale
+	}
+
+	return DEFAULT_LOCALE
+}
+
+// LocalizeError replaces every *MessageError within err, including nested
+// validation.Errors, with its message in locale from catalog
+//
+// If catalog is nil, DefaultCatalog is used
+// Any other error is returned as is
+func LocalizeError(err error, catalog *Catalog, locale string) error {
+	if catalog == nil {
+		catalog = DefaultCatalog
+	}
+
+	if e, ok := err.(validation.Errors); ok {
+		localized := make(validation.Errors, len(e))
+
+		for key, fieldErr := range e {
+			localized[key] = LocalizeError(fieldErr, catalog, locale)
+		}
+
+		return localized
+	}
+
+	// *MessageError can be wrapped, e.g. with errors.Wrap
+	var msgErr *MessageError
+
+	if errors.As(err, &msgErr) {
+		return errors.New(catalog.Message(locale, msgErr.Code, msgErr.Params))
+	}
+
+	return err
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Error returns message of DEFAULT_LOCALE from DefaultCatalog
+func (m *MessageError) Error() string {
+	return DefaultCatalog.Message(DEFAULT_LOCALE, m.Code, m.Params)
+}
+
+// Set adds messages, keyed by error code, to locale overriding
+// any existing message with the same code
+func (c *Catalog) Set(locale string, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locale = strings.ToLower(locale)
+
+	if _, ok := c.messages[locale]; !ok {
+		c.messages[locale] = make(map[string]string, len(messages))
+	}
+
+	for code, msg := range messages {
+		c.messages[locale][code] = msg
+	}
+}
+
+// Locales returns every locale that has messages
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+	return locales
+}
+
+// Message returns message of code in locale with params replacing
+// "{key}" placeholders
+//
+// If locale has no message for code, the base language of locale
+// is used, e.g. "es" for "es-MX", then DEFAULT_LOCALE and finally
+// the code itself
+func (c *Catalog) Message(locale, code string, params map[string]any) string {
+	c.mu.RLock()
+	msg, ok := c.lookup(locale, code)
+	if !ok {
+		if msg, ok = c.lookup(DEFAULT_LOCALE, code); !ok {
+			msg = code
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(params) == 0 {
+		return msg
+	}
+
+	oldNew := make([]string, 0, len(params)*2)
+
+	for key, val := range params {
+		oldNew = append(oldNew, "{"+key+"}", fmt.Sprint(val))
+	}
+
+	return strings.NewReplacer(oldNew...).Replace(msg)
+}
+
+// MatchLocale returns locale of catalog that best matches acceptLanguage,
+// the value of an "Accept-Language" header, based on quality values
+//
+// Returns empty string if no locale matches
+func (c *Catalog) MatchLocale(acceptLanguage string) string {
+	type langQuality struct {
+		lang    string
+		quality float64
+	}
+
+	langs := make([]langQuality, 0)
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if lang = strings.ToLower(strings.TrimSpace(lang)); lang == "" || lang == "*" || quality <= 0 {
+			continue
+		}
+
+		langs = append(langs, langQuality{lang: lang, quality: quality})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].quality > langs[j].quality
+	})
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, lq := range langs {
+		if _, ok := c.messages[lq.lang]; ok {
+			return lq.lang
+		}
+
+		if base, _, ok := strings.Cut(lq.lang, "-"); ok {
+			if _, ok = c.messages[base]; ok {
+				return base
+			}
+		}
+	}
+
+	return ""
+}
+
+// lookup returns message of code in locale or base language of locale
+func (c *Catalog) lookup(locale, code string) (string, bool) {
+	locale = strings.ToLower(locale)
+
+	if msg, ok := c.messages[locale][code]; ok {
+		return msg, true
+	}
+
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		if msg, ok := c.messages[base][code]; ok {
+			return msg, true
+		}
+	}
+
+	return "", false
+}
+
+func (m *messageRule) Validate(value any) error {
+	err := m.rule.Validate(value)
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(validation.InternalError); ok {
+		return err
+	}
+
+	return NewMessageError(m.code, m.params)
+}
@@ -0,0 +1,193 @@
+package webutil
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+func TestCatalogUnitTest(t *testing.T) {
+	catalog := NewCatalog()
+
+	tests := []struct {
+		locale   string
+		code     string
+		params   map[string]any
+		expected string
+	}{
+		{"en", REQUIRED_CODE, nil, REQUIRED_TXT},
+		{"es", REQUIRED_CODE, nil, "requerido"},
+		{"es-MX", ALREADY_EXISTS_CODE, nil, "ya existe"},
+		{"FR", DOES_NOT_EXIST_CODE, nil, "n'existe pas"},
+		{"fr", MIN_VALUE_CODE, map[string]any{"min": 5}, "Ne peut pas être inférieur à 5"},
+		{"en", MAX_VALUE_CODE, map[string]any{"max": 10.5}, "Can't be greater than 10.5"},
+		{"de", INVALID_CODE, nil, INVALID_TXT},
+		{"en", "unknown", nil, "unknown"},
+	}
+
+	for _, test := range tests {
+		if msg := catalog.Message(test.locale, test.code, test.params); msg != test.expected {
+			t.Errorf("%s %s: should have message '%s'; got '%s'\n", test.locale, test.code, test.expected, msg)
+		}
+	}
+
+	catalog.Set("de", map[string]string{REQUIRED_CODE: "erforderlich"})
+
+	if msg := catalog.Message("de-AT", REQUIRED_CODE, nil); msg != "erforderlich" {
+		t.Errorf("should have message 'erforderlich'; got '%s'\n", msg)
+	}
+	if msg := DefaultCatalog.Message("de", REQUIRED_CODE, nil); msg != REQUIRED_TXT {
+		t.Errorf("default catalog should not be changed; got '%s'\n", msg)
+	}
+
+	// ---------------------------------------------------------------
+
+	matches := map[string]string{
+		"":                                   "",
+		"fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5": "fr",
+		"de;q=0.9, es-MX;q=0.95":             "es",
+		"en;q=0.5, es":                       "es",
+		"de, *":                              "",
+		"es;q=0, en":                         "en",
+	}
+
+	for header, expected := range matches {
+		if locale := DefaultCatalog.MatchLocale(header); locale != expected {
+			t.Errorf("header '%s': should have locale '%s'; got '%s'\n", header, expected, locale)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/url", nil)
+	req.Header.Set("Accept-Language", "es-ES,es;q=0.9")
+
+	if locale := RequestLocale(req, nil); locale != "es" {
+		t.Errorf("should have locale 'es'; got '%s'\n", locale)
+	}
+
+	req = req.WithContext(WithLocale(req.Context(), "fr"))
+
+	if locale := RequestLocale(req, nil); locale != "fr" {
+		t.Errorf("should have locale 'fr'; got '%s'\n", locale)
+	}
+
+	if locale := RequestLocale(httptest.NewRequest(http.MethodGet, "/url", nil), nil); locale != DEFAULT_LOCALE {
+		t.Errorf("should have locale '%s'; got '%s'\n", DEFAULT_LOCALE, locale)
+	}
+}
+
+func TestLocalizeErrorUnitTest(t *testing.T) {
+	type form struct {
+		Name   string       `json:"name"`
+		Amount FormCurrency `json:"amount"`
+	}
+
+	min := decimal.NewFromInt(1)
+	fv := &FormValidation{}
+
+	f := form{Amount: FormCurrency{Decimal: decimal.Zero, Min: &min}}
+
+	err := validation.ValidateStruct(
+		&f,
+		validation.Field(&f.Name, RequiredRule),
+		validation.Field(&f.Amount),
+	)
+
+	if err.(validation.Errors)["name"].Error() != REQUIRED_TXT {
+		t.Errorf("should have english error by default\n")
+	}
+
+	localized := LocalizeError(err, nil, "es").(validation.Errors)
+
+	if localized["name"].Error() != "requerido" {
+		t.Errorf("should have 'requerido'; got '%s'\n", localized["name"].Error())
+	}
+	if localized["amount"].Error() != "No puede ser menor que 1" {
+		t.Errorf("should have 'No puede ser menor que 1'; got '%s'\n", localized["amount"].Error())
+	}
+
+	// ---------------------------------------------------------------
+
+	nested := validation.Errors{
+		"address": validation.Errors{"zip": NewMessageError(INVALID_FORMAT_CODE, nil)},
+		"other":   validation.NewInternalError(context.Canceled),
+	}
+
+	localized = LocalizeError(nested, nil, "fr").(validation.Errors)
+
+	if msg := localized["address"].(validation.Errors)["zip"].Error(); msg != "format invalide" {
+		t.Errorf("should have 'format invalide'; got '%s'\n", msg)
+	}
+	if _, ok := localized["other"].(validation.InternalError); !ok {
+		t.Errorf("should keep internal error\n")
+	}
+
+	wrapped := pkgerrors.Wrap(NewMessageError(REQUIRED_CODE, nil), "wrapped")
+
+	if msg := LocalizeError(wrapped, nil, "es").Error(); msg != "requerido" {
+		t.Errorf("should localize wrapped error; got '%s'\n", msg)
+	}
+
+	// ---------------------------------------------------------------
+
+	type rulesForm struct {
+		Name  string `json:"name" validate:"min=3"`
+		Count int    `json:"count" validate:"max=2"`
+	}
+
+	r := rulesForm{Name: "ab", Count: 3}
+	err = validation.Errors{
+		"rules": fv.ValidateStruct(WithLocale(context.Background(), "es"), &r),
+		"valid": fv.IsValid(false).Validate(nil),
+		"date":  fv.ValidateDate("", false, true, true).Validate(1),
+	}
+	localized = LocalizeError(err, nil, "es").(validation.Errors)
+
+	if msg := localized["rules"].(validation.Errors)["name"].Error(); msg != "la longitud no puede ser menor que 3" {
+		t.Errorf("should have localized min length; got '%s'\n", msg)
+	}
+	if msg := localized["rules"].(validation.Errors)["count"].Error(); msg != "No puede ser mayor que 2" {
+		t.Errorf("should have localized max value; got '%s'\n", msg)
+	}
+	if msg := localized["valid"].Error(); msg != "inválido" {
+		t.Errorf("should have 'inválido'; got '%s'\n", msg)
+	}
+	if msg := localized["date"].Error(); msg != "formato inválido" {
+		t.Errorf("should have 'formato inválido'; got '%s'\n", msg)
+	}
+
+	// ---------------------------------------------------------------
+
+	type userForm struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	u := userForm{Email: "foo"}
+	err = fv.ValidateStruct(WithLocale(context.Background(), "fr"), &u)
+
+	if errs, ok := err.(validation.Errors); !ok || errs["email"].Error() != "format invalide" {
+		t.Errorf("should have 'format invalide'; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	req := httptest.NewRequest(http.MethodPost, "/url", nil)
+	req.Header.Set("Accept-Language", "es")
+	rr := httptest.NewRecorder()
+
+	WriteRequestError(rr, req, validation.Errors{"name": NewMessageError(REQUIRED_CODE, nil)})
+
+	var body map[string]string
+
+	if err = json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if rr.Code != http.StatusUnprocessableEntity || body["name"] != "requerido" {
+		t.Errorf("should have 422 with 'requerido'; got %d %v\n", rr.Code, body)
+	}
+}
@@ -1,5 +1,7 @@
 package webutil
 
+import "time"
+
 //////////////////////////////////////////////////////////////////
 //---------------------- VALIDATOR TYPES -----------------------
 //////////////////////////////////////////////////////////////////
@@ -75,8 +77,142 @@ const (
 	// to be in the past
 	INVALID_PAST_DATE_TXT = "date can't be before current date/time"
 
+	// DATE_BEFORE_MIN_TXT is string const error when date is before "{min}"
+	DATE_BEFORE_MIN_TXT = "date can't be before {min}"
+
+	// DATE_AFTER_MAX_TXT is string const error when date is after "{max}"
+	DATE_AFTER_MAX_TXT = "date can't be after {max}"
+
 	// CANT_BE_NEGATIVE_TXT is sring const when field can't be negative
 	CANT_BE_NEGATIVE_TXT = "can't be negative"
+
+	// MIN_VALUE_TXT is string const error when field is less than "{min}"
+	MIN_VALUE_TXT = "Can't be less than {min}"
+
+	// MAX_VALUE_TXT is string const error when field is greater than "{max}"
+	MAX_VALUE_TXT = "Can't be greater than {max}"
+
+	// MIN_LENGTH_TXT is string const error when length of field is less than "{min}"
+	MIN_LENGTH_TXT = "length can't be less than {min}"
+
+	// MAX_LENGTH_TXT is string const error when length of field is greater than "{max}"
+	MAX_LENGTH_TXT = "length can't be greater than {max}"
+
+	// INVALID_CURRENCY_TXT is string const error when currency code is unknown
+	INVALID_CURRENCY_TXT = "invalid currency"
+
+	// INVALID_DECIMAL_PLACES_TXT is string const error when amount has more
+	// decimal places than its currency allows
+	INVALID_DECIMAL_PLACES_TXT = "can't have more than {places} decimal places"
+
+	// FIELDS_DONT_MATCH_TXT is string const error when field does not match other field
+	FIELDS_DONT_MATCH_TXT = "does not match"
+
+	// INVALID_DATE_RANGE_TXT is string const error when date is not after other date
+	INVALID_DATE_RANGE_TXT = "date must be after start date"
+
+	// MUTUALLY_EXCLUSIVE_TXT is string const error when field is set along with
+	// fields it can't be set with
+	MUTUALLY_EXCLUSIVE_TXT = "can't be set along with other fields"
+
+	// INVALID_FILE_COUNT_TXT is string const error when number of uploaded files is invalid
+	INVALID_FILE_COUNT_TXT = "invalid number of files"
+
+	// FILE_TOO_LARGE_TXT is string const error when uploaded file is too large
+	FILE_TOO_LARGE_TXT = "file too large"
+
+	// INVALID_FILE_TYPE_TXT is string const error when uploaded file type is not allowed
+	INVALID_FILE_TYPE_TXT = "invalid file type"
+
+	// INVALID_FILE_EXTENSION_TXT is string const error when uploaded file extension is not allowed
+	INVALID_FILE_EXTENSION_TXT = "invalid file extension"
+)
+
+//////////////////////////////////////////////////////////////////
+//---------------------- ERROR CODES ---------------------------
+//////////////////////////////////////////////////////////////////
+
+// Error codes are keys of messages within *Catalog
+const (
+	REQUIRED_CODE               = "required"
+	ALREADY_EXISTS_CODE         = "already_exists"
+	DOES_NOT_EXIST_CODE         = "does_not_exist"
+	INVALID_CODE                = "invalid"
+	INVALID_FORMAT_CODE         = "invalid_format"
+	INVALID_FUTURE_DATE_CODE    = "invalid_future_date"
+	INVALID_PAST_DATE_CODE      = "invalid_past_date"
+	DATE_BEFORE_MIN_CODE        = "date_before_min"
+	DATE_AFTER_MAX_CODE         = "date_after_max"
+	CANT_BE_NEGATIVE_CODE       = "cant_be_negative"
+	MIN_VALUE_CODE              = "min_value"
+	MAX_VALUE_CODE              = "max_value"
+	MIN_LENGTH_CODE             = "min_length"
+	MAX_LENGTH_CODE             = "max_length"
+	INVALID_CURRENCY_CODE       = "invalid_currency"
+	INVALID_DECIMAL_PLACES_CODE = "invalid_decimal_places"
+	FIELDS_DONT_MATCH_CODE      = "fields_dont_match"
+	INVALID_DATE_RANGE_CODE     = "invalid_date_range"
+	MUTUALLY_EXCLUSIVE_CODE     = "mutually_exclusive"
+	INVALID_FILE_COUNT_CODE     = "invalid_file_count"
+	FILE_TOO_LARGE_CODE         = "file_too_large"
+	INVALID_FILE_TYPE_CODE      = "invalid_file_type"
+	INVALID_FILE_EXTENSION_CODE = "invalid_file_extension"
+)
+
+//////////////////////////////////////////////////////////////////
+//---------------------- ROUNDING MODES ------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// ROUND_NONE does not round and instead amounts with more decimal
+	// places than currency allows are invalid
+	ROUND_NONE RoundingMode = iota
+
+	// ROUND_HALF_UP rounds half away from zero, e.g. 1.005 -> 1.01
+	ROUND_HALF_UP
+
+	// ROUND_HALF_EVEN rounds half to nearest even digit, e.g. 1.005 -> 1.00
+	ROUND_HALF_EVEN
+
+	// ROUND_UP rounds away from zero
+	ROUND_UP
+
+	// ROUND_DOWN rounds towards zero
+	ROUND_DOWN
+
+	// ROUND_CEIL rounds towards positive infinity
+	ROUND_CEIL
+
+	// ROUND_FLOOR rounds towards negative infinity
+	ROUND_FLOOR
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------ DST POLICIES ------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// DST_GAP_SHIFT moves nonexistent times forward by the length of
+	// the gap, e.g. 02:30 becomes 03:30 when clocks skip 02:00 to 03:00
+	DST_GAP_SHIFT DSTGapPolicy = iota
+
+	// DST_GAP_TRANSITION moves nonexistent times to the transition,
+	// e.g. 02:30 becomes 03:00 when clocks skip 02:00 to 03:00
+	DST_GAP_TRANSITION
+
+	// DST_GAP_ERROR returns ErrNonexistentTime for nonexistent times
+	DST_GAP_ERROR
+)
+
+const (
+	// DST_OVERLAP_EARLIER uses the first occurrence of ambiguous times
+	DST_OVERLAP_EARLIER DSTOverlapPolicy = iota
+
+	// DST_OVERLAP_LATER uses the second occurrence of ambiguous times
+	DST_OVERLAP_LATER
+
+	// DST_OVERLAP_ERROR returns ErrAmbiguousTime for ambiguous times
+	DST_OVERLAP_ERROR
 )
 
 //////////////////////////////////////////////////////////////////
@@ -123,6 +259,23 @@ const (
 	SSL_VERIFY_FULL_MODE = "verify-full"
 )
 
+//////////////////////////////////////////////////////////////////
+//----------------------- SMTP SECURITY ------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// SMTP_SECURITY_STARTTLS connects in plain text and requires
+	// the server to upgrade the connection with STARTTLS
+	SMTP_SECURITY_STARTTLS SMTPSecurity = iota
+
+	// SMTP_SECURITY_TLS connects with implicit TLS, usually port 465
+	SMTP_SECURITY_TLS
+
+	// SMTP_SECURITY_NONE never encrypts connection and should only
+	// be used for local development servers
+	SMTP_SECURITY_NONE
+)
+
 //////////////////////////////////////////////////////////////////
 //---------------------- DRIVERS ------------------------
 //////////////////////////////////////////////////////////////////
@@ -149,9 +302,15 @@ const (
 	// FORM_CONTENT_HEADER is key string for content type header "application/x-www-form-urlencoded"
 	FORM_CONTENT_HEADER = "application/x-www-form-urlencoded"
 
+	// MULTIPART_FORM_CONTENT_HEADER is key string for content type header "multipart/form-data"
+	MULTIPART_FORM_CONTENT_HEADER = "multipart/form-data"
+
 	// JSON_CONTENT_HEADER is key string for content type header "application/json"
 	JSON_CONTENT_HEADER = "application/json"
 
+	// PROBLEM_JSON_CONTENT_HEADER is key string for content type header "application/problem+json"
+	PROBLEM_JSON_CONTENT_HEADER = "application/problem+json"
+
 	// PDF_CONTENT_HEADER is key string for content type header "application/pdf"
 	PDF_CONTENT_HEADER = "application/pdf"
 
@@ -206,9 +365,94 @@ const (
 	// DB_CONN_STR is default format for a connection string to a database
 	DB_CONN_STR = "%s://%s:%s@%s:%d/%s?&sslmode=%s&sslrootcert=%s&sslkey=%s&sslcert=%s&search_path=%s"
 
+	// DEFAULT_LOCALE is locale used when no locale is found for request
+	DEFAULT_LOCALE = "en"
+
+	// VALIDATE_TAG is struct tag used by FormValidation#ValidateStruct
+	VALIDATE_TAG = "validate"
+
 	// INT_BASE is default base to use for converting string to int64
 	INT_BASE = 10
 
 	// INT_BIT_SIZE is default bit size to use for converting string to int64
 	INT_BIT_SIZE = 64
+
+	// DEFAULT_PING_ATTEMPTS is default number of times to ping database on startup
+	DEFAULT_PING_ATTEMPTS = 5
+
+	// DEFAULT_PING_BACKOFF is default initial wait between ping attempts
+	DEFAULT_PING_BACKOFF = time.Millisecond * 500
+
+	// DEFAULT_BATCH_CONCURRENCY is default number of batched validation
+	// queries run at the same time
+	DEFAULT_BATCH_CONCURRENCY = 4
+
+	// DEFAULT_MULTIPART_MAX_MEMORY is default number of bytes of uploaded
+	// files kept in memory before spooling to disk
+	DEFAULT_MULTIPART_MAX_MEMORY = 32 << 20
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------ MIGRATIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// DEFAULT_MIGRATION_TABLE is default table used to record applied migrations
+	DEFAULT_MIGRATION_TABLE = "schema_migrations"
+
+	// DEFAULT_MIGRATION_LOCK_ID is default key used for advisory lock
+	// while running migrations
+	DEFAULT_MIGRATION_LOCK_ID = 7239012847
+)
+
+//////////////////////////////////////////////////////////////////
+//----------------------- EMAIL LIMITS -------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// EMAIL_LIMIT_RECIPIENT is reason of EmailLimitError when a
+	// recipient has been sent too many messages
+	EMAIL_LIMIT_RECIPIENT = "recipient"
+
+	// EMAIL_LIMIT_GLOBAL is reason of EmailLimitError when too
+	// many messages have been sent in total
+	EMAIL_LIMIT_GLOBAL = "global"
+
+	// EMAIL_LIMIT_DUPLICATE is reason of EmailLimitError when an
+	// identical message was recently sent
+	EMAIL_LIMIT_DUPLICATE = "duplicate"
+)
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- OUTBOX ----------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// DEFAULT_OUTBOX_TABLE is default table outbox messages are stored in
+	DEFAULT_OUTBOX_TABLE = "email_outbox"
+
+	// OUTBOX_STATUS_PENDING is status of message waiting to be sent
+	OUTBOX_STATUS_PENDING = "pending"
+
+	// OUTBOX_STATUS_SENDING is status of message claimed by a worker
+	OUTBOX_STATUS_SENDING = "sending"
+
+	// OUTBOX_STATUS_SENT is status of message that was sent
+	OUTBOX_STATUS_SENT = "sent"
+
+	// OUTBOX_STATUS_FAILED is status of message that reached max attempts
+	// and will not be retried unless requeued
+	OUTBOX_STATUS_FAILED = "failed"
+)
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- TOKENS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+const (
+	// DEFAULT_TOKEN_NONCE_TABLE is default table consumed token nonces are stored in
+	DEFAULT_TOKEN_NONCE_TABLE = "token_nonces"
+
+	// TOKEN_COOKIE_NAME is name action tokens are signed with
+	TOKEN_COOKIE_NAME = "webutil_action_token"
 )
@@ -0,0 +1,222 @@
+package webutil
+
+import (
+	"reflect"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- RULES -----------------------------
+//////////////////////////////////////////////////////////////////
+
+// The rules below compare the validated field against other fields of
+// the same form so the error is keyed under the validated field
+//
+// Other fields should be passed as pointers so their value is read
+// when the rule is run, e.g.
+//
+//	validation.ValidateStruct(
+//		&form,
+//		validation.Field(&form.State, fv.RequiredIf(&form.Country, "US")),
+//		validation.Field(&form.ConfirmPassword, fv.EqualToField(&form.Password)),
+//		validation.Field(&form.EndDate, fv.DateAfterField(&form.StartDate)),
+//	)
+
+// RequiredIf makes field required when otherField is equal to value
+func (f *FormValidation) RequiredIf(otherField any, value any) *requiredIfRule {
+	return &requiredIfRule{
+		otherField: otherField,
+		value:      value,
+		err:        NewMessageError(REQUIRED_CODE, nil),
+	}
+}
+
+// RequiredWith makes field required when any of otherFields is not empty
+func (f *FormValidation) RequiredWith(otherFields ...any) *requiredWithRule {
+	return &requiredWithRule{
+		otherFields: otherFields,
+		err:         NewMessageError(REQUIRED_CODE, nil),
+	}
+}
+
+// EqualToField verifies field has the same value as otherField,
+// e.g. password confirmation
+//
+// Empty fields are skipped so RequiredRule should be used as well
+// if field can't be empty
+func (f *FormValidation) EqualToField(otherField any) *equalToFieldRule {
+	return &equalToFieldRule{
+		otherField: otherField,
+		err:        NewMessageError(FIELDS_DONT_MATCH_CODE, nil),
+	}
+}
+
+// DateAfterField verifies field is a date after otherField
+//
+// If either date is empty, the rule is skipped
+func (f *FormValidation) DateAfterField(otherField any) *dateAfterFieldRule {
+	return &dateAfterFieldRule{
+		otherField: otherField,
+		err:        NewMessageError(INVALID_DATE_RANGE_CODE, nil),
+	}
+}
+
+// MutuallyExclusive verifies none of otherFields are set
+// when field is not empty
+func (f *FormValidation) MutuallyExclusive(otherFields ...any) *mutuallyExclusiveRule {
+	return &mutuallyExclusiveRule{
+		otherFields: otherFields,
+		err:         NewMessageError(MUTUALLY_EXCLUSIVE_CODE, nil),
+	}
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+type requiredIfRule struct {
+	otherField any
+	value      any
+	err        error
+}
+
+func (r *requiredIfRule) Validate(value any) error {
+	other, _ := validation.Indirect(r.otherField)
+	expected, _ := validation.Indirect(r.value)
+
+	if !reflect.DeepEqual(other, expected) {
+		return nil
+	}
+
+	return (&validateRequiredRule{err: r.err}).Validate(value)
+}
+
+func (r *requiredIfRule) Error(message string) *requiredIfRule {
+	r.err = errors.New(message)
+	return r
+}
+
+type requiredWithRule struct {
+	otherFields []any
+	err         error
+}
+
+func (r *requiredWithRule) Validate(value any) error {
+	for _, other := range r.otherFields {
+		if !isNilValue(other) {
+			return (&validateRequiredRule{err: r.err}).Validate(value)
+		}
+	}
+
+	return nil
+}
+
+func (r *requiredWithRule) Error(message string) *requiredWithRule {
+	r.err = errors.New(message)
+	return r
+}
+
+type equalToFieldRule struct {
+	otherField any
+	err        error
+}
+
+func (r *equalToFieldRule) Validate(value any) error {
+	if isNilValue(value) {
+		return nil
+	}
+
+	val, _ := validation.Indirect(value)
+	other, _ := validation.Indirect(r.otherField)
+
+	if !reflect.DeepEqual(val, other) {
+		return r.err
+	}
+
+	return nil
+}
+
+func (r *equalToFieldRule) Error(message string) *equalToFieldRule {
+	r.err = errors.New(message)
+	return r
+}
+
+type dateAfterFieldRule struct {
+	otherField any
+	err        error
+}
+
+func (r *dateAfterFieldRule) Validate(value any) error {
+	if isNilValue(value) || isNilValue(r.otherField) {
+		return nil
+	}
+
+	dateTime, err := ruleTime(value)
+	if err != nil {
+		return err
+	}
+
+	otherTime, err := ruleTime(r.otherField)
+	if err != nil {
+		return validation.NewInternalError(err)
+	}
+
+	if !dateTime.After(otherTime) {
+		return r.err
+	}
+
+	return nil
+}
+
+func (r *dateAfterFieldRule) Error(message string) *dateAfterFieldRule {
+	r.err = errors.New(message)
+	return r
+}
+
+type mutuallyExclusiveRule struct {
+	otherFields []any
+	err         error
+}
+
+func (r *mutuallyExclusiveRule) Validate(value any) error {
+	if isNilValue(value) {
+		return nil
+	}
+
+	for _, other := range r.otherFields {
+		if !isNilValue(other) {
+			return r.err
+		}
+	}
+
+	return nil
+}
+
+func (r *mutuallyExclusiveRule) Error(message string) *mutuallyExclusiveRule {
+	r.err = errors.New(message)
+	return r
+}
+
+// ruleTime returns time.Time from value used within date rules
+// or *MessageError of INVALID_FORMAT_CODE for other types
+func ruleTime(value any) (time.Time, error) {
+	switch val := value.(type) {
+	case time.Time:
+		return val, nil
+	case *time.Time:
+		return *val, nil
+	case FormDate:
+		return val.Time, nil
+	case *FormDate:
+		return val.Time, nil
+	case FormDateTime:
+		return val.Time, nil
+	case *FormDateTime:
+		return val.Time, nil
+	default:
+		return time.Time{}, NewMessageError(INVALID_FORMAT_CODE, nil)
+	}
+}
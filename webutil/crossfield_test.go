@@ -0,0 +1,125 @@
+package webutil
+
+import (
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+func TestCrossFieldRulesUnitTest(t *testing.T) {
+	type signupForm struct {
+		Country         string     `json:"country"`
+		State           string     `json:"state"`
+		Phone           string     `json:"phone"`
+		PhoneType       string     `json:"phoneType"`
+		Password        string     `json:"password"`
+		ConfirmPassword string     `json:"confirmPassword"`
+		StartDate       time.Time  `json:"startDate"`
+		EndDate         *time.Time `json:"endDate"`
+		Email           string     `json:"email"`
+		Username        string     `json:"username"`
+	}
+
+	fv := &FormValidation{}
+	now := time.Now()
+	before := now.Add(-time.Hour)
+
+	validate := func(form *signupForm) error {
+		return validation.ValidateStruct(
+			form,
+			validation.Field(&form.State, fv.RequiredIf(&form.Country, "US")),
+			validation.Field(&form.PhoneType, fv.RequiredWith(&form.Phone)),
+			validation.Field(&form.ConfirmPassword, fv.EqualToField(&form.Password)),
+			validation.Field(&form.EndDate, fv.DateAfterField(&form.StartDate)),
+			validation.Field(&form.Email, fv.MutuallyExclusive(&form.Username)),
+		)
+	}
+
+	tests := []struct {
+		name   string
+		form   signupForm
+		errMap map[string]string
+	}{
+		{
+			"valid",
+			signupForm{
+				Country:         "US",
+				State:           "NY",
+				Phone:           "555-555-5555",
+				PhoneType:       "mobile",
+				Password:        "secret",
+				ConfirmPassword: "secret",
+				StartDate:       before,
+				EndDate:         &now,
+				Email:           "foo@email.com",
+			},
+			nil,
+		},
+		{
+			"empty",
+			signupForm{Country: "CA"},
+			nil,
+		},
+		{
+			"invalid",
+			signupForm{
+				Country:         "US",
+				Phone:           "555-555-5555",
+				Password:        "secret",
+				ConfirmPassword: "secrets",
+				StartDate:       now,
+				EndDate:         &before,
+				Email:           "foo@email.com",
+				Username:        "foo",
+			},
+			map[string]string{
+				"state":           REQUIRED_TXT,
+				"phoneType":       REQUIRED_TXT,
+				"confirmPassword": FIELDS_DONT_MATCH_TXT,
+				"endDate":         INVALID_DATE_RANGE_TXT,
+				"email":           MUTUALLY_EXCLUSIVE_TXT,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := validate(&test.form)
+
+		if test.errMap == nil {
+			if err != nil {
+				t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+			}
+
+			continue
+		}
+
+		errs, ok := err.(validation.Errors)
+		if !ok {
+			t.Fatalf("%s: should have validation.Errors; got %v\n", test.name, err)
+		}
+
+		if len(errs) != len(test.errMap) {
+			t.Errorf("%s: should have %d errors; got %d\n", test.name, len(test.errMap), len(errs))
+		}
+
+		for key, msg := range test.errMap {
+			if errs[key] == nil || errs[key].Error() != msg {
+				t.Errorf("%s: field %s should have error '%s'; got %v\n", test.name, key, msg, errs[key])
+			}
+		}
+	}
+
+	// ---------------------------------------------------------------
+
+	form := signupForm{EndDate: &now}
+	err := validation.Validate(form.EndDate, fv.DateAfterField("foo"))
+
+	if _, ok := err.(validation.InternalError); !ok {
+		t.Errorf("should have internal error; got %v\n", err)
+	}
+
+	if err = validation.Validate("bar", fv.EqualToField("foo").Error("custom")); err == nil || err.Error() != "custom" {
+		t.Errorf("should have custom error; got %v\n", err)
+	}
+}
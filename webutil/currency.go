@@ -0,0 +1,464 @@
+package webutil
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- TYPES ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// RoundingMode determines how a decimal is rounded to the
+// number of decimal places of a currency
+type RoundingMode int
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Money is an amount in an ISO 4217 currency
+//
+// Arithmetic between two Money values is only allowed with the
+// same currency and the amount is serialized as string so no
+// precision is lost, e.g. {"amount": "12.34", "currency": "USD"}
+type Money struct {
+	Amount   decimal.Decimal
+	Currency string
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+// currencySeparators are the group and decimal separators of a locale
+type currencySeparators struct {
+	group   string
+	decimal string
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	// CurrencyDecimalPlaces is the number of minor unit decimal places
+	// of ISO 4217 currency codes
+	//
+	// Currencies can be added to support codes not listed
+	CurrencyDecimalPlaces = map[string]int32{
+		"AED": 2, "ARS": 2, "AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2,
+		"CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2,
+		"EGP": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+		"ILS": 2, "INR": 2, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+		"KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2, "NZD": 2, "OMR": 3,
+		"PEN": 2, "PHP": 2, "PLN": 2, "RON": 2, "RUB": 2, "SAR": 2,
+		"SEK": 2, "SGD": 2, "THB": 2, "TND": 3, "TRY": 2, "TWD": 2,
+		"UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+	}
+
+	// localeSeparators maps base language of locale to its separators
+	localeSeparators = map[string]currencySeparators{
+		"en": {group: ",", decimal: "."},
+		"ja": {group: ",", decimal: "."},
+		"zh": {group: ",", decimal: "."},
+		"es": {group: ".", decimal: ","},
+		"de": {group: ".", decimal: ","},
+		"it": {group: ".", decimal: ","},
+		"nl": {group: ".", decimal: ","},
+		"pt": {group: ".", decimal: ","},
+		"fr": {group: " ", decimal: ","},
+	}
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// CurrencyPlaces returns number of decimal places of ISO 4217 currency code
+func CurrencyPlaces(currency string) (int32, bool) {
+	places, ok := CurrencyDecimalPlaces[strings.ToUpper(currency)]
+	return places, ok
+}
+
+// RoundCurrency rounds d to the number of decimal places of currency
+// using mode
+//
+// Returns ErrUnknownCurrency if currency is not within CurrencyDecimalPlaces
+func RoundCurrency(d decimal.Decimal, currency string, mode RoundingMode) (decimal.Decimal, error) {
+	places, ok := CurrencyPlaces(currency)
+	if !ok {
+		return d, errors.Wrapf(ErrUnknownCurrency, "currency %q", currency)
+	}
+
+	return roundDecimal(d, places, mode), nil
+}
+
+// ParseLocaleDecimal parses decimal formatted for locale, e.g. "1.234,56"
+// for "es" or "1,234.56" for "en"
+//
+// The amount can have a sign and a currency symbol or ISO code before or
+// after it and wrapping parentheses are treated as negative.  Group
+// separators must separate groups of 3 digits and any other character
+// returns error so typos aren't parsed as a different amount
+// If locale is not supported, the separators of DEFAULT_LOCALE are used
+func ParseLocaleDecimal(s, locale string) (decimal.Decimal, error) {
+	base, _, _ := strings.Cut(strings.ToLower(locale), "-")
+
+	seps, ok := localeSeparators[base]
+	if !ok {
+		seps = localeSeparators[DEFAULT_LOCALE]
+	}
+
+	input := s
+	invalidErr := errors.Errorf("webutil: invalid amount %q", input)
+	negative, hasSign, hasCurrency := false, false, false
+
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, hasSign = true, true
+		s = s[1 : len(s)-1]
+	}
+
+	// Affixes are stripped from both ends, allowing one sign and
+	// one currency symbol or code in total
+	for _, fromEnd := range []bool{false, true} {
+		for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+			idx := 0
+			if fromEnd {
+				idx = len(s) - 1
+			}
+
+			if s[idx] == '-' || s[idx] == '+' {
+				if hasSign {
+					return decimal.Zero, invalidErr
+				}
+
+				negative, hasSign = s[idx] == '-', true
+				s = s[:idx] + s[idx+1:]
+				continue
+			}
+
+			currency := localeCurrencyAffix(s, fromEnd)
+			if currency == "" {
+				break
+			}
+			if hasCurrency {
+				return decimal.Zero, invalidErr
+			}
+
+			hasCurrency = true
+
+			if fromEnd {
+				s = s[:len(s)-len(currency)]
+			} else {
+				s = s[len(currency):]
+			}
+		}
+	}
+
+	number, ok := normalizeLocaleNumber(s, seps)
+	if !ok {
+		return decimal.Zero, invalidErr
+	}
+
+	d, err := decimal.NewFromString(number)
+	if err != nil {
+		return decimal.Zero, errors.Wrapf(err, "webutil: invalid amount %q", input)
+	}
+
+	if negative {
+		d = d.Neg()
+	}
+
+	return d, nil
+}
+
+// localeCurrencyAffix returns currency symbol or ISO code at the start
+// of s, or at the end if fromEnd is true, otherwise empty string
+func localeCurrencyAffix(s string, fromEnd bool) string {
+	var r rune
+	var size int
+
+	if fromEnd {
+		r, size = utf8.DecodeLastRuneInString(s)
+	} else {
+		r, size = utf8.DecodeRuneInString(s)
+	}
+
+	if unicode.Is(unicode.Sc, r) {
+		if fromEnd {
+			return s[len(s)-size:]
+		}
+
+		return s[:size]
+	}
+
+	if len(s) < 3 {
+		return ""
+	}
+
+	code := s[:3]
+	if fromEnd {
+		code = s[len(s)-3:]
+	}
+
+	for _, c := range code {
+		if !unicode.IsLetter(c) || c > unicode.MaxASCII {
+			return ""
+		}
+	}
+
+	if _, ok := CurrencyPlaces(code); !ok {
+		return ""
+	}
+
+	return code
+}
+
+// normalizeLocaleNumber converts s using seps to a number that can be
+// parsed by decimal, validating that group separators are at 3 digit
+// group boundaries
+func normalizeLocaleNumber(s string, seps currencySeparators) (string, bool) {
+	intPart, fracPart, hasDecimal := strings.Cut(s, seps.decimal)
+
+	if hasDecimal && !isASCIIDigits(fracPart) {
+		return "", false
+	}
+
+	if seps.group == " " {
+		// Locales grouping with spaces commonly use non breaking spaces
+		intPart = strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return ' '
+			}
+
+			return r
+		}, intPart)
+	}
+
+	if intPart == "" {
+		if !hasDecimal {
+			return "", false
+		}
+
+		return "0." + fracPart, true
+	}
+
+	groups := strings.Split(intPart, seps.group)
+
+	for i, digits := range groups {
+		if !isASCIIDigits(digits) {
+			return "", false
+		}
+		if len(groups) > 1 && (i == 0 && len(digits) > 3 || i > 0 && len(digits) != 3) {
+			return "", false
+		}
+	}
+
+	number := strings.Join(groups, "")
+
+	if hasDecimal {
+		number += "." + fracPart
+	}
+
+	return number, true
+}
+
+// isASCIIDigits determines whether s is non empty and only contains 0-9
+func isASCIIDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewMoney returns Money instance of amount in currency
+//
+// Returns ErrUnknownCurrency if currency is not within CurrencyDecimalPlaces
+func NewMoney(amount decimal.Decimal, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+
+	if _, ok := CurrencyPlaces(currency); !ok {
+		return Money{}, errors.Wrapf(ErrUnknownCurrency, "currency %q", currency)
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// roundDecimal rounds d to places using mode
+func roundDecimal(d decimal.Decimal, places int32, mode RoundingMode) decimal.Decimal {
+	switch mode {
+	case ROUND_NONE:
+		return d
+	case ROUND_HALF_EVEN:
+		return d.RoundBank(places)
+	case ROUND_UP:
+		return d.RoundUp(places)
+	case ROUND_DOWN:
+		return d.RoundDown(places)
+	case ROUND_CEIL:
+		return d.RoundCeil(places)
+	case ROUND_FLOOR:
+		return d.RoundFloor(places)
+	default:
+		return d.Round(places)
+	}
+}
+
+// hasValidPlaces determines whether d has no more significant
+// decimal places than currency allows
+func hasValidPlaces(d decimal.Decimal, currency string) (int32, bool, bool) {
+	places, ok := CurrencyPlaces(currency)
+	if !ok {
+		return 0, false, false
+	}
+
+	return places, true, d.Equal(d.Truncate(places))
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Add returns m + other
+//
+// Returns ErrCurrencyMismatch if currencies are different
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount.Add(other.Amount), Currency: m.Currency}, nil
+}
+
+// Sub returns m - other
+//
+// Returns ErrCurrencyMismatch if currencies are different
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: m.Amount.Sub(other.Amount), Currency: m.Currency}, nil
+}
+
+// Mul returns m * factor rounded to the decimal places of currency with mode
+func (m Money) Mul(factor decimal.Decimal, mode RoundingMode) (Money, error) {
+	return Money{Amount: m.Amount.Mul(factor), Currency: m.Currency}.Round(mode)
+}
+
+// Cmp compares m and other returning -1, 0 or 1
+//
+// Returns ErrCurrencyMismatch if currencies are different
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return 0, err
+	}
+
+	return m.Amount.Cmp(other.Amount), nil
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Amount: m.Amount.Neg(), Currency: m.Currency}
+}
+
+// IsZero determines whether amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// Round rounds amount to decimal places of currency with mode
+func (m Money) Round(mode RoundingMode) (Money, error) {
+	amount, err := RoundCurrency(m.Amount, m.Currency, mode)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// String returns amount with decimal places of currency followed by
+// currency, e.g. "12.30 USD"
+func (m Money) String() string {
+	if places, ok := CurrencyPlaces(m.Currency); ok {
+		return m.Amount.StringFixed(places) + " " + m.Currency
+	}
+
+	return m.Amount.String() + " " + m.Currency
+}
+
+// MarshalJSON returns {"amount": "12.30", "currency": "USD"} with
+// amount always having the decimal places of currency
+func (m Money) MarshalJSON() ([]byte, error) {
+	amount := m.Amount.String()
+
+	if places, ok := CurrencyPlaces(m.Currency); ok {
+		amount = m.Amount.StringFixed(places)
+	}
+
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{
+		Amount:   amount,
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON decodes {"amount": "12.30", "currency": "USD"}
+// where amount can be either string or number
+func (m *Money) UnmarshalJSON(b []byte) error {
+	var mj moneyJSON
+
+	if err := json.Unmarshal(b, &mj); err != nil {
+		return err
+	}
+
+	m.Currency = strings.ToUpper(mj.Currency)
+	m.Amount = decimal.Zero
+
+	if len(mj.Amount) == 0 || string(mj.Amount) == "null" {
+		return nil
+	}
+
+	return m.Amount.UnmarshalJSON(mj.Amount)
+}
+
+// Validate verifies currency is known and amount does not have more
+// decimal places than currency allows
+func (m Money) Validate() error {
+	places, known, valid := hasValidPlaces(m.Amount, m.Currency)
+
+	if !known {
+		return NewMessageError(INVALID_CURRENCY_CODE, map[string]any{"currency": m.Currency})
+	}
+	if !valid {
+		return NewMessageError(INVALID_DECIMAL_PLACES_CODE, map[string]any{"places": places})
+	}
+
+	return nil
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if !strings.EqualFold(m.Currency, other.Currency) {
+		return errors.Wrapf(ErrCurrencyMismatch, "%s and %s", m.Currency, other.Currency)
+	}
+
+	return nil
+}
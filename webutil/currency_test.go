@@ -0,0 +1,226 @@
+package webutil
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestRoundCurrencyUnitTest(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		mode     RoundingMode
+		expected string
+	}{
+		{"1.005", "USD", ROUND_HALF_UP, "1.01"},
+		{"1.005", "usd", ROUND_HALF_EVEN, "1"},
+		{"1.001", "USD", ROUND_UP, "1.01"},
+		{"1.009", "USD", ROUND_DOWN, "1"},
+		{"-1.001", "USD", ROUND_CEIL, "-1"},
+		{"-1.001", "USD", ROUND_FLOOR, "-1.01"},
+		{"1234.5", "JPY", ROUND_HALF_UP, "1235"},
+		{"1.2345", "BHD", ROUND_HALF_UP, "1.235"},
+		{"1.2345", "BHD", ROUND_NONE, "1.2345"},
+	}
+
+	for _, test := range tests {
+		d, err := RoundCurrency(decimal.RequireFromString(test.amount), test.currency, test.mode)
+		if err != nil {
+			t.Fatalf("should not have error; got %s\n", err.Error())
+		}
+
+		if d.String() != test.expected {
+			t.Errorf("%s %s: should have %s; got %s\n", test.amount, test.currency, test.expected, d.String())
+		}
+	}
+
+	if _, err := RoundCurrency(decimal.Zero, "XXX", ROUND_HALF_UP); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("should have ErrUnknownCurrency; got %v\n", err)
+	}
+}
+
+func TestParseLocaleDecimalUnitTest(t *testing.T) {
+	tests := []struct {
+		input    string
+		locale   string
+		expected string
+		hasErr   bool
+	}{
+		{"1.234,56", "es", "1234.56", false},
+		{"1.234,56 €", "de-DE", "1234.56", false},
+		{"1,234.56", "en-US", "1234.56", false},
+		{"$1,234.56", "en", "1234.56", false},
+		{"1 234,56", "fr", "1234.56", false},
+		{"-1.234,5", "pt-BR", "-1234.5", false},
+		{"(12.50)", "en", "-12.5", false},
+		{"12.50 USD", "xx", "12.5", false},
+		{"1.234.567,8", "es", "1234567.8", false},
+		{"1\u00a0234,56", "fr-FR", "1234.56", false},
+		{"USD 1,234", "en", "1234", false},
+		{"$-5", "en", "-5", false},
+		{"5-", "en", "-5", false},
+		{",5", "es", "0.5", false},
+		{"1.2.3,4", "es", "", true},
+		{"1.5", "es", "", true},
+		{"1,23.4", "en", "", true},
+		{"1234,567.8", "en", "", true},
+		{"12abc34", "en", "", true},
+		{"abc12", "en", "", true},
+		{"1-2", "en", "", true},
+		{"--5", "en", "", true},
+		{"-(5)", "en", "", true},
+		{"$5 USD", "en", "", true},
+		{"1,234.", "en", "", true},
+		{"1 234", "en", "", true},
+		{"12#50", "en", "", true},
+		{"-", "en", "", true},
+		{"", "en", "", true},
+	}
+
+	for _, test := range tests {
+		d, err := ParseLocaleDecimal(test.input, test.locale)
+
+		if test.hasErr {
+			if err == nil {
+				t.Errorf("%q: should have error\n", test.input)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%q: should not have error; got %s\n", test.input, err.Error())
+		} else if d.String() != test.expected {
+			t.Errorf("%q: should have %s; got %s\n", test.input, test.expected, d.String())
+		}
+	}
+}
+
+func TestFormCurrencyUnitTest(t *testing.T) {
+	var err error
+
+	tests := []struct {
+		input    string
+		currency string
+		rounding RoundingMode
+		locale   string
+		expected string
+		errMsg   string
+	}{
+		{`12.34`, "USD", ROUND_NONE, "", "12.34", ""},
+		{`12.345`, "USD", ROUND_NONE, "", "12.345", "can't have more than 2 decimal places"},
+		{`12.345`, "USD", ROUND_HALF_EVEN, "", "12.34", ""},
+		{`1200.5`, "JPY", ROUND_NONE, "", "1200.5", "can't have more than 0 decimal places"},
+		{`1.234`, "BHD", ROUND_NONE, "", "1.234", ""},
+		{`"1.234,567"`, "BHD", ROUND_NONE, "es", "1234.567", ""},
+		{`"1.234,5678"`, "BHD", ROUND_HALF_UP, "es", "1234.568", ""},
+		{`12.3`, "XXX", ROUND_NONE, "", "12.3", INVALID_CURRENCY_TXT},
+	}
+
+	for _, test := range tests {
+		f := FormCurrency{Currency: test.currency, Rounding: test.rounding, Locale: test.locale}
+
+		if err = json.Unmarshal([]byte(test.input), &f); err != nil {
+			t.Fatalf("%s: should not have error; got %s\n", test.input, err.Error())
+		}
+
+		if f.Decimal.String() != test.expected {
+			t.Errorf("%s: should have %s; got %s\n", test.input, test.expected, f.Decimal.String())
+		}
+
+		err = f.Validate()
+
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: should not have error; got %s\n", test.input, err.Error())
+			}
+		} else if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: should have error '%s'; got %v\n", test.input, test.errMsg, err)
+		}
+	}
+
+	f := FormCurrency{Locale: "es"}
+
+	if err = json.Unmarshal([]byte(`"12#5"`), &f); err == nil {
+		t.Errorf("should have error\n")
+	}
+}
+
+func TestMoneyUnitTest(t *testing.T) {
+	usd, err := NewMoney(decimal.RequireFromString("10.10"), "usd")
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if _, err = NewMoney(decimal.Zero, "XXX"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("should have ErrUnknownCurrency; got %v\n", err)
+	}
+
+	sum, err := usd.Add(Money{Amount: decimal.RequireFromString("0.2"), Currency: "USD"})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if sum.String() != "10.30 USD" {
+		t.Errorf("should have '10.30 USD'; got '%s'\n", sum.String())
+	}
+
+	diff, err := usd.Sub(sum)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if !diff.Amount.Equal(decimal.RequireFromString("-0.2")) {
+		t.Errorf("should have -0.2; got %s\n", diff.Amount.String())
+	}
+
+	product, err := usd.Mul(decimal.RequireFromString("0.333"), ROUND_HALF_UP)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if product.String() != "3.36 USD" {
+		t.Errorf("should have '3.36 USD'; got '%s'\n", product.String())
+	}
+
+	eur := Money{Amount: decimal.NewFromInt(1), Currency: "EUR"}
+
+	if _, err = usd.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("should have ErrCurrencyMismatch; got %v\n", err)
+	}
+	if _, err = usd.Cmp(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("should have ErrCurrencyMismatch; got %v\n", err)
+	}
+	if cmp, _ := usd.Cmp(sum); cmp != -1 {
+		t.Errorf("should have -1; got %d\n", cmp)
+	}
+
+	// ---------------------------------------------------------------
+
+	b, err := json.Marshal(Money{Amount: decimal.NewFromInt(5), Currency: "BHD"})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if string(b) != `{"amount":"5.000","currency":"BHD"}` {
+		t.Errorf("should have bhd json; got %s\n", string(b))
+	}
+
+	var m Money
+
+	for _, input := range []string{`{"amount":"12.5","currency":"eur"}`, `{"amount":12.5,"currency":"EUR"}`} {
+		if err = json.Unmarshal([]byte(input), &m); err != nil {
+			t.Fatalf("should not have error; got %s\n", err.Error())
+		}
+		if m.String() != "12.50 EUR" {
+			t.Errorf("should have '12.50 EUR'; got '%s'\n", m.String())
+		}
+	}
+
+	if err = json.Unmarshal([]byte(`{"amount":"abc","currency":"EUR"}`), &m); err == nil {
+		t.Errorf("should have error\n")
+	}
+
+	if err = (Money{Amount: decimal.RequireFromString("1.5"), Currency: "JPY"}).Validate(); err == nil {
+		t.Errorf("should have error\n")
+	}
+}
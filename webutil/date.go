@@ -0,0 +1,321 @@
+package webutil
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// FormDate is date received from a form in FORM_DATE_LAYOUT or DATE_LAYOUT
+// which is stored as midnight of the date within Timezone
+//
+// The zero value is marshaled as null, stored as NULL and fails RequiredRule
+// and validation.Required
+type FormDate struct {
+	time.Time
+
+	// Timezone is IANA timezone the date is parsed in
+	//
+	// Default: "UTC"
+	Timezone string `json:"-"`
+}
+
+// FormDateTime is date and time received from a form in FORM_DATE_TIME_LAYOUT,
+// DATE_TIME_LAYOUT or RFC 3339
+//
+// Values without an offset are parsed as wall clock time within Timezone
+// and values with an offset are converted to Timezone
+//
+// The zero value is marshaled as null, stored as NULL and fails RequiredRule
+// and validation.Required
+type FormDateTime struct {
+	time.Time
+
+	// Timezone is IANA timezone the date and time is parsed in
+	//
+	// Default: "UTC"
+	Timezone string `json:"-"`
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	formDateLayouts = []string{
+		FORM_DATE_LAYOUT,
+		DATE_LAYOUT,
+	}
+
+	formDateTimeLayouts = []string{
+		FORM_DATE_TIME_LAYOUT,
+		strings.ToUpper(FORM_DATE_TIME_LAYOUT),
+		DATE_TIME_LAYOUT,
+		DATE_TIME_OFFSET_LAYOUT,
+		time.RFC3339Nano,
+		FORM_DATE_LAYOUT,
+		DATE_LAYOUT,
+	}
+
+	// dbDateTimeLayouts are layouts drivers return for text date columns
+	dbDateTimeLayouts = []string{
+		time.RFC3339Nano,
+		DATE_TIME_OFFSET_LAYOUT,
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05.999999999",
+		DATE_LAYOUT,
+	}
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewFormDate returns FormDate of date of t within timezone
+func NewFormDate(t time.Time, timezone string) (FormDate, error) {
+	loc, err := formLocation(timezone)
+	if err != nil {
+		return FormDate{}, err
+	}
+
+	t = t.In(loc)
+
+	return FormDate{
+		Time:     time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc),
+		Timezone: timezone,
+	}, nil
+}
+
+// NewFormDateTime returns FormDateTime of t within timezone
+func NewFormDateTime(t time.Time, timezone string) (FormDateTime, error) {
+	loc, err := formLocation(timezone)
+	if err != nil {
+		return FormDateTime{}, err
+	}
+
+	return FormDateTime{Time: t.In(loc), Timezone: timezone}, nil
+}
+
+// ParseFormDate parses value with the first of layouts that matches within
+// timezone, converting values that have an offset to timezone
+func ParseFormDate(value, timezone string, layouts ...string) (time.Time, error) {
+	loc, err := formLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return parseInLocation(value, loc, layouts)
+}
+
+func formLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	return LoadLocation(timezone)
+}
+
+func parseInLocation(value string, loc *time.Location, layouts []string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("webutil: invalid date %q", value)
+}
+
+// scanFormTime converts value returned from database driver to time
+func scanFormTime(value any, loc *time.Location) (time.Time, error) {
+	switch val := value.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return val.In(loc), nil
+	case string:
+		return parseInLocation(val, loc, dbDateTimeLayouts)
+	case []byte:
+		return parseInLocation(string(val), loc, dbDateTimeLayouts)
+	default:
+		return time.Time{}, errors.Errorf("webutil: invalid data type %T for date", value)
+	}
+}
+
+// unquoteFormTime returns json string of b or empty string for null
+func unquoteFormTime(b []byte) (string, error) {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		return "", nil
+	}
+
+	var s string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return "", err
+	}
+
+	return s, nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FORMDATE ---------------------------
+//////////////////////////////////////////////////////////////////
+
+// MarshalJSON returns date in FORM_DATE_LAYOUT
+func (f FormDate) MarshalJSON() ([]byte, error) {
+	if f.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(f.Format(FORM_DATE_LAYOUT))
+}
+
+func (f *FormDate) UnmarshalJSON(b []byte) error {
+	s, err := unquoteFormTime(b)
+	if err != nil {
+		return err
+	}
+
+	return f.UnmarshalText([]byte(s))
+}
+
+// MarshalText returns date in FORM_DATE_LAYOUT
+func (f FormDate) MarshalText() ([]byte, error) {
+	if f.IsZero() {
+		return []byte{}, nil
+	}
+
+	return []byte(f.Format(FORM_DATE_LAYOUT)), nil
+}
+
+func (f *FormDate) UnmarshalText(text []byte) error {
+	if len(bytes.TrimSpace(text)) == 0 {
+		f.Time = time.Time{}
+		return nil
+	}
+
+	t, err := ParseFormDate(string(text), f.Timezone, formDateLayouts...)
+	if err != nil {
+		return err
+	}
+
+	f.Time = t
+	return nil
+}
+
+// Scan implements sql.Scanner interface
+func (f *FormDate) Scan(value any) error {
+	loc, err := formLocation(f.Timezone)
+	if err != nil {
+		return err
+	}
+
+	t, err := scanFormTime(value, time.UTC)
+	if err != nil {
+		return err
+	}
+
+	if t.IsZero() {
+		f.Time = t
+		return nil
+	}
+
+	// Date columns have no timezone so keep the date itself
+	f.Time = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	return nil
+}
+
+// Value implements driver.Valuer interface and returns date in DATE_LAYOUT
+func (f FormDate) Value() (driver.Value, error) {
+	if f.IsZero() {
+		return nil, nil
+	}
+
+	return f.Format(DATE_LAYOUT), nil
+}
+
+//////////////////////////////////////////////////////////////////
+//----------------------- FORMDATETIME -------------------------
+//////////////////////////////////////////////////////////////////
+
+// MarshalJSON returns date and time in FORM_DATE_TIME_LAYOUT
+func (f FormDateTime) MarshalJSON() ([]byte, error) {
+	if f.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(f.Format(FORM_DATE_TIME_LAYOUT))
+}
+
+func (f *FormDateTime) UnmarshalJSON(b []byte) error {
+	s, err := unquoteFormTime(b)
+	if err != nil {
+		return err
+	}
+
+	return f.UnmarshalText([]byte(s))
+}
+
+// MarshalText returns date and time in FORM_DATE_TIME_LAYOUT
+func (f FormDateTime) MarshalText() ([]byte, error) {
+	if f.IsZero() {
+		return []byte{}, nil
+	}
+
+	return []byte(f.Format(FORM_DATE_TIME_LAYOUT)), nil
+}
+
+func (f *FormDateTime) UnmarshalText(text []byte) error {
+	if len(bytes.TrimSpace(text)) == 0 {
+		f.Time = time.Time{}
+		return nil
+	}
+
+	t, err := ParseFormDate(string(text), f.Timezone, formDateTimeLayouts...)
+	if err != nil {
+		return err
+	}
+
+	f.Time = t
+	return nil
+}
+
+// Scan implements sql.Scanner interface, values without an offset are
+// treated as UTC and converted to Timezone
+func (f *FormDateTime) Scan(value any) error {
+	loc, err := formLocation(f.Timezone)
+	if err != nil {
+		return err
+	}
+
+	t, err := scanFormTime(value, time.UTC)
+	if err != nil {
+		return err
+	}
+
+	if t.IsZero() {
+		f.Time = t
+		return nil
+	}
+
+	f.Time = t.In(loc)
+	return nil
+}
+
+// Value implements driver.Valuer interface and returns time in UTC
+func (f FormDateTime) Value() (driver.Value, error) {
+	if f.IsZero() {
+		return nil, nil
+	}
+
+	return f.UTC(), nil
+}
@@ -0,0 +1,181 @@
+package webutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+func TestFormDateUnitTest(t *testing.T) {
+	type form struct {
+		Date     FormDate     `json:"date"`
+		DateTime FormDateTime `json:"dateTime"`
+	}
+
+	f := form{
+		Date:     FormDate{Timezone: "America/New_York"},
+		DateTime: FormDateTime{Timezone: "America/New_York"},
+	}
+
+	if err := json.Unmarshal([]byte(`{"date": "03/15/2024", "dateTime": "03/15/2024 3:30 pm"}`), &f); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if f.Date.Location().String() != "America/New_York" || f.Date.Format(DATE_TIME_LAYOUT) != "2024-03-15 00:00:00" {
+		t.Errorf("should have date in new york; got %s\n", f.Date.String())
+	}
+	if f.DateTime.UTC().Format(DATE_TIME_LAYOUT) != "2024-03-15 19:30:00" {
+		t.Errorf("should have 19:30 utc; got %s\n", f.DateTime.UTC().String())
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if string(b) != `{"date":"03/15/2024","dateTime":"03/15/2024 3:30 pm"}` {
+		t.Errorf("should have form layouts; got %s\n", string(b))
+	}
+
+	// ---------------------------------------------------------------
+
+	inputs := map[string]string{
+		`"2024-03-15"`:                "2024-03-15 00:00:00",
+		`"2024-03-15 10:00:00"`:       "2024-03-15 10:00:00",
+		`"03/15/2024 10:00 AM"`:       "2024-03-15 10:00:00",
+		`"2024-03-15T10:00:00-07:00"`: "2024-03-15 17:00:00",
+	}
+
+	for input, expected := range inputs {
+		var dt FormDateTime
+
+		if err = json.Unmarshal([]byte(input), &dt); err != nil {
+			t.Errorf("%s: should not have error; got %s\n", input, err.Error())
+		} else if dt.Format(DATE_TIME_LAYOUT) != expected {
+			t.Errorf("%s: should have %s; got %s\n", input, expected, dt.Format(DATE_TIME_LAYOUT))
+		}
+	}
+
+	var empty form
+
+	if err = json.Unmarshal([]byte(`{"date": null, "dateTime": ""}`), &empty); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if !empty.Date.IsZero() || !empty.DateTime.IsZero() {
+		t.Errorf("should be zero\n")
+	}
+	if b, _ = json.Marshal(empty); string(b) != `{"date":null,"dateTime":null}` {
+		t.Errorf("should have nulls; got %s\n", string(b))
+	}
+
+	for _, input := range []string{`"15/03/2024"`, `"foo"`, `5`} {
+		var d FormDate
+
+		if err = json.Unmarshal([]byte(input), &d); err == nil {
+			t.Errorf("%s: should have error\n", input)
+		}
+	}
+
+	d := FormDate{Timezone: "invalid"}
+
+	if err = json.Unmarshal([]byte(`"03/15/2024"`), &d); err == nil {
+		t.Errorf("should have timezone error\n")
+	}
+}
+
+func TestFormDateSQLUnitTest(t *testing.T) {
+	d := FormDate{Timezone: "America/New_York"}
+
+	if err := d.Scan(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if val, _ := d.Value(); val != "2024-03-15" {
+		t.Errorf("should keep date 2024-03-15; got %v\n", val)
+	}
+	if err := d.Scan([]byte("2024-03-16")); err != nil || d.Day() != 16 {
+		t.Errorf("should have 16th; got %s %v\n", d.String(), err)
+	}
+	if err := d.Scan(nil); err != nil || !d.IsZero() {
+		t.Errorf("should be zero; got %s %v\n", d.String(), err)
+	}
+	if val, _ := d.Value(); val != nil {
+		t.Errorf("should have nil value; got %v\n", val)
+	}
+
+	// ---------------------------------------------------------------
+
+	dt := FormDateTime{Timezone: "America/New_York"}
+
+	if err := dt.Scan("2024-03-15 19:30:00"); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if dt.Hour() != 15 {
+		t.Errorf("should convert utc to new york; got %s\n", dt.String())
+	}
+
+	val, _ := dt.Value()
+	if val.(time.Time).Location() != time.UTC || val.(time.Time).Hour() != 19 {
+		t.Errorf("should have utc value; got %v\n", val)
+	}
+	if err := dt.Scan(5); err == nil {
+		t.Errorf("should have error\n")
+	}
+}
+
+func TestFormDateValidateUnitTest(t *testing.T) {
+	fv := &FormValidation{}
+	now := time.Now()
+
+	yesterday, _ := NewFormDate(now.AddDate(0, 0, -1), "UTC")
+	tomorrow, _ := NewFormDateTime(now.AddDate(0, 0, 1), "UTC")
+
+	tests := []struct {
+		name   string
+		value  any
+		rule   validation.Rule
+		errMsg string
+	}{
+		{"past ok", yesterday, fv.ValidateDate("", false, false, true), ""},
+		{"past future", &tomorrow, fv.ValidateDate("", true, false, true), INVALID_FUTURE_DATE_TXT},
+		{"future past", yesterday, fv.ValidateDate("", false, true, false), INVALID_PAST_DATE_TXT},
+		{"custom", yesterday, fv.ValidateDate("", false, true, false).Error("custom"), "custom"},
+		{
+			"min",
+			yesterday,
+			fv.ValidateDate("", false, true, true).Min(now),
+			"date can't be before " + now.UTC().Format(FORM_DATE_LAYOUT),
+		},
+		{
+			"max",
+			tomorrow,
+			fv.ValidateDate("", false, true, true).Max(now),
+			"date can't be after " + now.UTC().Format(FORM_DATE_LAYOUT),
+		},
+		{"within", now.UTC().Format(FORM_DATE_LAYOUT), fv.ValidateDate("", false, true, true).Min(now).Max(now), ""},
+		{"empty", FormDate{}, fv.ValidateDate("", false, false, true), ""},
+	}
+
+	for _, test := range tests {
+		err := validation.Validate(test.value, test.rule)
+
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+			}
+		} else if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: should have error '%s'; got %v\n", test.name, test.errMsg, err)
+		}
+	}
+
+	if err := validation.Validate(FormDate{}, RequiredRule); err == nil {
+		t.Errorf("should have required error\n")
+	}
+	if err := validation.Validate(FormDateTime{}, validation.Required); err == nil {
+		t.Errorf("should have required error\n")
+	}
+
+	if err := validation.Validate(tomorrow, fv.DateAfterField(yesterday)); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+}
@@ -0,0 +1,68 @@
+package webutil
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OpenDatabase opens a connection pool based on setting, applying
+// pool settings and pinging database with exponential backoff
+// until PingAttempts is reached
+//
+// The driver for DriverName (or DBType if not set) must be
+// registered by importing it before calling OpenDatabase
+func OpenDatabase(setting DatabaseSetting) (*sql.DB, error) {
+	dsn, err := setting.DSN()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	driverName := setting.DriverName
+	if driverName == "" {
+		driverName = setting.DBType
+	}
+
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	db.SetMaxOpenConns(setting.MaxOpenConns)
+	db.SetConnMaxLifetime(setting.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(setting.ConnMaxIdleTime)
+
+	if setting.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(setting.MaxIdleConns)
+	}
+
+	attempts := setting.PingAttempts
+	if attempts < 1 {
+		attempts = DEFAULT_PING_ATTEMPTS
+	}
+
+	backoff := setting.PingBackoff
+	if backoff <= 0 {
+		backoff = DEFAULT_PING_BACKOFF
+	}
+
+	for i := 1; ; i++ {
+		if err = db.PingContext(context.Background()); err == nil {
+			return db, nil
+		}
+
+		if i == attempts {
+			break
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	db.Close()
+	return nil, errors.Wrapf(err, "webutil: could not ping database after %d attempts", attempts)
+}
+
+var _ Database = (*sql.DB)(nil)
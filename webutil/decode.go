@@ -0,0 +1,245 @@
+package webutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// DecodeOptions is config struct used in CheckBodyAndDecodeWithOptions
+type DecodeOptions struct {
+	// MaxBodyBytes is max size of request body allowed
+	//
+	// Default: 0 (no limit)
+	MaxBodyBytes int64
+
+	// DisallowUnknownFields returns error if body contains field
+	// that is not in form
+	DisallowUnknownFields bool
+
+	// SingleValue returns error if body contains anything
+	// after the first json value
+	SingleValue bool
+
+	// ContentTypes are the media types allowed in the "Content-Type"
+	// header of request, e.g. JSON_CONTENT_HEADER
+	//
+	// Default: nil (any content type)
+	ContentTypes []string
+
+	// UseNumber decodes numbers into json.Number instead of float64
+	// when decoding into any
+	UseNumber bool
+
+	// ExcludeMethods are http methods that skip decoding
+	// if request has no body
+	ExcludeMethods []string
+}
+
+// DecodeError is returned from CheckBodyAndDecodeWithOptions when
+// body is not valid json or does not match form
+//
+// DecodeError wraps ErrInvalidJSON so errors.Is(err, ErrInvalidJSON)
+// is true
+type DecodeError struct {
+	// Field is the dot separated path of field that caused error if known
+	Field string
+
+	// Offset is byte offset within body where error occurred
+	Offset int64
+
+	// Msg is short description of error
+	Msg string
+
+	// Err is the underlying decode error
+	Err error
+}
+
+func (d *DecodeError) Error() string {
+	if d.Field != "" {
+		return fmt.Sprintf("field %s: %s", d.Field, d.Msg)
+	}
+
+	return fmt.Sprintf("offset %d: %s", d.Offset, d.Msg)
+}
+
+func (d *DecodeError) Unwrap() []error {
+	return []error{d.Err, ErrInvalidJSON}
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// CheckBodyAndDecodeWithOptions is CheckBodyAndDecode with stricter options
+//
+// The w parameter is passed to http.MaxBytesReader when MaxBodyBytes is set
+// so the server can close the connection and may be nil
+//
+// Returns ErrUnsupportedContentType if "Content-Type" header does not match
+// ContentTypes, ErrBodyTooLarge if body is larger than MaxBodyBytes,
+// ErrBodyRequired if request has no body and *DecodeError if body is invalid
+func CheckBodyAndDecodeWithOptions(w http.ResponseWriter, req *http.Request, form any, opts DecodeOptions) error {
+	canSkip := false
+
+	for _, v := range opts.ExcludeMethods {
+		if req.Method == v {
+			canSkip = true
+			break
+		}
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		if canSkip {
+			return nil
+		}
+
+		return ErrBodyRequired
+	}
+
+	if len(opts.ContentTypes) > 0 {
+		mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return ErrUnsupportedContentType
+		}
+
+		allowed := false
+
+		for _, contentType := range opts.ContentTypes {
+			if ct, _, _ := mime.ParseMediaType(contentType); strings.EqualFold(ct, mediaType) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return ErrUnsupportedContentType
+		}
+	}
+
+	body := req.Body
+
+	if opts.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(w, req.Body, opts.MaxBodyBytes)
+	}
+
+	dec := json.NewDecoder(body)
+
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(form); err != nil {
+		if errors.Is(err, io.EOF) {
+			if canSkip {
+				return nil
+			}
+
+			return ErrBodyRequired
+		}
+
+		return decodeError(dec, err)
+	}
+
+	if opts.SingleValue {
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			if err != nil && !isSyntaxError(err) {
+				return decodeError(dec, err)
+			}
+
+			return &DecodeError{
+				Offset: dec.InputOffset(),
+				Msg:    "body must only contain a single json value",
+				Err:    err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeError converts error from json decoder into *DecodeError
+// or ErrBodyTooLarge
+func decodeError(dec *json.Decoder, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return ErrBodyTooLarge
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{
+			Offset: syntaxErr.Offset,
+			Msg:    syntaxErr.Error(),
+			Err:    err,
+		}
+	case errors.As(err, &typeErr):
+		return &DecodeError{
+			Field:  typeErr.Field,
+			Offset: typeErr.Offset,
+			Msg:    "expected " + jsonTypeName(typeErr.Type),
+			Err:    err,
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{
+			Offset: dec.InputOffset(),
+			Msg:    "unexpected end of json",
+			Err:    err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return &DecodeError{
+			Field:  strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`),
+			Offset: dec.InputOffset(),
+			Msg:    "unknown field",
+			Err:    err,
+		}
+	default:
+		return &DecodeError{
+			Offset: dec.InputOffset(),
+			Msg:    err.Error(),
+			Err:    err,
+		}
+	}
+}
+
+func isSyntaxError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr)
+}
+
+// jsonTypeName returns the json name for kind of t
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+
+	switch deref(t).Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return t.String()
+	}
+}
@@ -0,0 +1,78 @@
+package webutil
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckBodyAndDecodeWithOptionsUnitTest(t *testing.T) {
+	type item struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+		Tags  []struct {
+			ID int `json:"id"`
+		} `json:"tags"`
+	}
+
+	opts := DecodeOptions{
+		MaxBodyBytes:          64,
+		DisallowUnknownFields: true,
+		SingleValue:           true,
+		ContentTypes:          []string{JSON_CONTENT_HEADER},
+	}
+
+	newRequest := func(body, contentType string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		ct     string
+		errMsg string
+		target error
+	}{
+		{"valid", `{"name": "foo", "price": 1.5}`, JSON_CONTENT_HEADER + "; charset=utf-8", "", nil},
+		{"empty", ``, JSON_CONTENT_HEADER, "", ErrBodyRequired},
+		{"content type", `{}`, TEXT_CONTENT_HEADER, "", ErrUnsupportedContentType},
+		{"too large", `{"name": "` + strings.Repeat("a", 100) + `"}`, JSON_CONTENT_HEADER, "", ErrBodyTooLarge},
+		{"wrong type", `{"price": "1.5"}`, JSON_CONTENT_HEADER, "field price: expected number", ErrInvalidJSON},
+		{"unknown field", `{"foo": 1}`, JSON_CONTENT_HEADER, "field foo: unknown field", ErrInvalidJSON},
+		{"syntax", `{"name": }`, JSON_CONTENT_HEADER, "offset 10: invalid character '}' looking for beginning of value", ErrInvalidJSON},
+		{"trailing", `{"name": "foo"} garbage`, JSON_CONTENT_HEADER, "offset 15: body must only contain a single json value", ErrInvalidJSON},
+	}
+
+	for _, test := range tests {
+		var form item
+
+		err := CheckBodyAndDecodeWithOptions(httptest.NewRecorder(), newRequest(test.body, test.ct), &form, opts)
+
+		if test.target == nil {
+			if err != nil {
+				t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+			}
+
+			continue
+		}
+
+		if !errors.Is(err, test.target) {
+			t.Errorf("%s: should have %v error; got %v\n", test.name, test.target, err)
+			continue
+		}
+
+		if test.errMsg != "" && err.Error() != test.errMsg {
+			t.Errorf("%s: error should be %q; got %q\n", test.name, test.errMsg, err.Error())
+		}
+	}
+
+	opts.ExcludeMethods = []string{http.MethodPost}
+
+	if err := CheckBodyAndDecodeWithOptions(nil, newRequest("", JSON_CONTENT_HEADER), &item{}, opts); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+}
@@ -1,59 +1,788 @@
 package webutil
 
 import (
+	"crypto/tls"
+	"encoding/base64"
 	"fmt"
-	"log"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
+	"gopkg.in/gomail.v2"
 )
 
-type EmailMessage struct {
-	FromEmail   string
-	FromName    string
-	ToEmail     string
-	ToName      string
-	Subject     string
-	PlainText   string
-	HTML        string
-	Attachments []mail.Attachment
-}
+//////////////////////////////////////////////////////////////////
+//----------------------- INTERFACES -------------------------
+//////////////////////////////////////////////////////////////////
 
 // EmailSender defines the interface for sending emails.
 type EmailSender interface {
 	SendEmail(msg ...EmailMessage) error
 }
 
+//////////////////////////////////////////////////////////////////
+//-------------------------- TYPES ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// SMTPSecurity determines how connection to smtp server is encrypted
+type SMTPSecurity int
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// EmailAddress is email address with optional display name
+type EmailAddress struct {
+	Email string
+	Name  string
+}
+
+// EmailAttachment is file attached to EmailMessage
+type EmailAttachment struct {
+	// Filename is name of file shown to recipient
+	Filename string
+
+	// ContentType is mime type of Content
+	//
+	// Default: type of Filename extension else "application/octet-stream"
+	ContentType string
+
+	// Content is raw content of file
+	Content []byte
+
+	// ContentID embeds attachment inline where html can reference
+	// it with "cid:<ContentID>"
+	ContentID string
+}
+
+// EmailMessage is message sent by EmailSender where at least
+// one To recipient is required
+type EmailMessage struct {
+	From    EmailAddress
+	To      []EmailAddress
+	CC      []EmailAddress
+	BCC     []EmailAddress
+	ReplyTo EmailAddress
+
+	Subject   string
+	PlainText string
+	HTML      string
+
+	// Headers are additional headers of message
+	Headers map[string]string
+
+	Attachments []EmailAttachment
+
+	// Categories group messages for provider analytics and are
+	// ignored by senders that don't support them
+	Categories []string
+}
+
+// EmailResult is result of sending single EmailMessage
+type EmailResult struct {
+	// Index is index of message within messages sent
+	Index int
+
+	// MessageID is id provider assigned to message if any
+	MessageID string
+
+	// Err is error sending message
+	Err error
+}
+
+// EmailSendError is returned when one or more messages
+// fail to send and contains result of every message
+type EmailSendError struct {
+	Results []EmailResult
+}
+
+func (e *EmailSendError) Error() string {
+	errs := e.Unwrap()
+
+	if len(errs) == 0 {
+		return "webutil: failed to send emails"
+	}
+
+	return fmt.Sprintf("webutil: %d of %d emails failed to send: %s", len(errs), len(e.Results), errs[0].Error())
+}
+
+// Unwrap returns errors of failed messages
+func (e *EmailSendError) Unwrap() []error {
+	errs := make([]error, 0)
+
+	for _, result := range e.Results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+
+	return errs
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- SENDGRID ---------------------------
+//////////////////////////////////////////////////////////////////
+
+// SendGridConfig is config for SendGrid
+type SendGridConfig struct {
+	// APIKey is SendGrid api key
+	APIKey string
+
+	// Host is base url of SendGrid api
+	//
+	// Default: "https://api.sendgrid.com"
+	Host string
+}
+
 // SendGrid implements EmailSender for SendGrid.
 type SendGrid struct {
-	apiKey string
+	mu     sync.Mutex
+	client *sendgrid.Client
 }
 
 // NewSendGrid creates a new SendGrid.
 func NewSendGrid(apiKey string) *SendGrid {
-	return &SendGrid{apiKey: apiKey}
+	return NewSendGridWithConfig(SendGridConfig{APIKey: apiKey})
+}
+
+// NewSendGridWithConfig creates a new SendGrid from config
+func NewSendGridWithConfig(config SendGridConfig) *SendGrid {
+	request := sendgrid.GetRequest(config.APIKey, "/v3/mail/send", config.Host)
+	request.Method = http.MethodPost
+
+	return &SendGrid{client: &sendgrid.Client{Request: request}}
 }
 
-// SendEmail sends an email using the SendGrid API.
+// SendEmail sends every message using the SendGrid API and returns
+// *EmailSendError if any message fails
 func (s *SendGrid) SendEmail(msgs ...EmailMessage) error {
+	results := s.SendEmailResults(msgs...)
+
+	for _, result := range results {
+		if result.Err != nil {
+			return &EmailSendError{Results: results}
+		}
+	}
+
+	return nil
+}
+
+// SendEmailResults sends every message using the SendGrid API
+// and returns result of each message
+func (s *SendGrid) SendEmailResults(msgs ...EmailMessage) []EmailResult {
+	// Client stores request body on itself so requests
+	// can't be sent concurrently
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]EmailResult, 0, len(msgs))
+
+	for i, msg := range msgs {
+		result := EmailResult{Index: i}
+		result.MessageID, result.Err = s.send(msg)
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func (s *SendGrid) send(msg EmailMessage) (string, error) {
+	message, err := newSendGridMessage(msg)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := s.client.Send(message)
+	if err != nil {
+		return "", fmt.Errorf("webutil: error sending email via SendGrid: %w", err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("webutil: SendGrid API error: status %d, body %s", response.StatusCode, response.Body)
+	}
+
+	if ids := response.Headers["X-Message-Id"]; len(ids) > 0 {
+		return ids[0], nil
+	}
+
+	return "", nil
+}
+
+//////////////////////////////////////////////////////////////////
+//--------------------------- SMTP -----------------------------
+//////////////////////////////////////////////////////////////////
+
+// SMTPConfig is config for SMTPSender
+type SMTPConfig struct {
+	// Host is host of smtp server
+	Host string
+
+	// Port is port of smtp server
+	//
+	// Default: 465 for SMTP_SECURITY_TLS else 587
+	Port int
+
+	// Username is used to authenticate with PLAIN auth
+	// Authentication is skipped if empty
+	Username string
+
+	// Password is used to authenticate with PLAIN auth
+	Password string
+
+	// Security determines how connection is encrypted
+	//
+	// Default: SMTP_SECURITY_STARTTLS
+	Security SMTPSecurity
+
+	// TLSConfig is used for SMTP_SECURITY_TLS and SMTP_SECURITY_STARTTLS
+	//
+	// Default: &tls.Config{ServerName: Host}
+	TLSConfig *tls.Config
+
+	// LocalName is name sent with EHLO
+	//
+	// Default: "localhost"
+	LocalName string
+
+	// Timeout is max duration of connecting and of sending each message
+	//
+	// Default: 30 seconds
+	Timeout time.Duration
+}
+
+// SMTPSender implements EmailSender for smtp servers
+//
+// Connection is handled with net/smtp rather than gomail.Dialer as
+// gomail only upgrades to STARTTLS when offered, so it can neither
+// require SMTP_SECURITY_STARTTLS nor honour SMTP_SECURITY_NONE, and
+// it has no configurable Timeout, where messages are still built
+// with gomail
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender returns *SMTPSender with defaults applied to config
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	if config.Port == 0 {
+		if config.Security == SMTP_SECURITY_TLS {
+			config.Port = 465
+		} else {
+			config.Port = 587
+		}
+	}
+	if config.TLSConfig == nil {
+		config.TLSConfig = &tls.Config{ServerName: config.Host}
+	}
+	if config.LocalName == "" {
+		config.LocalName = "localhost"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	return &SMTPSender{config: config}
+}
+
+// SendEmail sends msgs over a single connection to smtp server and
+// returns *EmailSendError if any message fails
+func (s *SMTPSender) SendEmail(msgs ...EmailMessage) error {
+	results := s.SendEmailResults(msgs...)
+
+	for _, result := range results {
+		if result.Err != nil {
+			return &EmailSendError{Results: results}
+		}
+	}
+
+	return nil
+}
+
+// SendEmailResults sends msgs over a single connection to smtp server
+// and returns result of each message
+//
+// A failed message doesn't stop the rest from being sent, where the
+// connection is reopened if it can't be reset after the failure
+func (s *SMTPSender) SendEmailResults(msgs ...EmailMessage) []EmailResult {
+	var conn net.Conn
+	var client *smtp.Client
+	var err error
+
+	results := make([]EmailResult, 0, len(msgs))
+
+	for i, msg := range msgs {
+		if client == nil {
+			// Remaining messages can't be sent if server can't be reached
+			if conn, client, err = s.dial(); err != nil {
+				for ; i < len(msgs); i++ {
+					results = append(results, EmailResult{Index: i, Err: err})
+				}
+
+				return results
+			}
+		}
+
+		result := EmailResult{Index: i}
+
+		if result.Err = s.send(conn, client, msg); result.Err != nil {
+			if conn.SetDeadline(time.Now().Add(s.config.Timeout)) != nil || client.Reset() != nil {
+				client.Close()
+				client = nil
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	// Messages have already been accepted so failing
+	// to quit doesn't fail them
+	if client != nil && client.Quit() != nil {
+		client.Close()
+	}
+
+	return results
+}
+
+// dial connects, encrypts and authenticates with smtp server
+func (s *SMTPSender) dial() (net.Conn, *smtp.Client, error) {
+	var conn net.Conn
+	var err error
+
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+
+	if s.config.Security == SMTP_SECURITY_TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, s.config.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	if err = conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		conn.Close()
+		return nil, nil, errors.WithStack(err)
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, nil, errors.WithStack(err)
+	}
+
+	if err = s.hello(client); err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+
+	return conn, client, nil
+}
+
+func (s *SMTPSender) hello(client *smtp.Client) error {
+	if err := client.Hello(s.config.LocalName); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if s.config.Security == SMTP_SECURITY_STARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("webutil: smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.config.TLSConfig); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if s.config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("webutil: smtp server does not support AUTH")
+		}
+
+		// smtp.PlainAuth refuses to send credentials over unencrypted
+		// connections unless host is localhost
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+
+		if err := client.Auth(auth); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SMTPSender) send(conn net.Conn, client *smtp.Client, msg EmailMessage) error {
+	m, err := newMailMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	if err = conn.SetDeadline(time.Now().Add(s.config.Timeout)); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err = client.Mail(msg.From.Email); err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, to := range emailRecipients(msg) {
+		if err = client.Rcpt(to); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if _, err = m.WriteTo(w); err != nil {
+		w.Close()
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(w.Close())
+}
+
+//////////////////////////////////////////////////////////////////
+//--------------------------- FILE -----------------------------
+//////////////////////////////////////////////////////////////////
+
+// FileEmailSender implements EmailSender by writing each message
+// to its own .eml file within a directory, which can be opened
+// by most mail clients
+type FileEmailSender struct {
+	dir string
+}
+
+// NewFileEmailSender returns *FileEmailSender that writes to dir,
+// which is created if it does not exist
+func NewFileEmailSender(dir string) *FileEmailSender {
+	return &FileEmailSender{dir: dir}
+}
+
+// SendEmail writes msgs to files named by time sent
+func (f *FileEmailSender) SendEmail(msgs ...EmailMessage) error {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+
 	for _, msg := range msgs {
-		from := mail.NewEmail(msg.FromName, msg.FromEmail)
-		to := mail.NewEmail(msg.ToName, msg.ToEmail)
-		message := mail.NewSingleEmail(from, msg.Subject, to, msg.PlainText, msg.HTML)
+		m, err := newMailMessage(msg)
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), uuid.NewString())
 
-		client := sendgrid.NewSendClient(s.apiKey)
-		response, err := client.Send(message)
+		file, err := os.Create(filepath.Join(f.dir, name))
 		if err != nil {
-			return fmt.Errorf("error sending email via SendGrid: %w", err)
+			return errors.WithStack(err)
+		}
+
+		if _, err = m.WriteTo(file); err != nil {
+			file.Close()
+			return errors.WithStack(err)
+		}
+
+		if err = file.Close(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- MEMORY ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// MemoryEmailSender implements EmailSender by recording messages
+// in memory and is meant to be used within tests
+type MemoryEmailSender struct {
+	mu       sync.RWMutex
+	messages []EmailMessage
+	err      error
+}
+
+// NewMemoryEmailSender returns *MemoryEmailSender
+func NewMemoryEmailSender() *MemoryEmailSender {
+	return &MemoryEmailSender{}
+}
+
+// SendEmail records msgs or returns error set by SetError
+func (m *MemoryEmailSender) SendEmail(msgs ...EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	m.messages = append(m.messages, msgs...)
+	return nil
+}
+
+// SetError sets error that is returned by SendEmail until
+// it is set back to nil
+func (m *MemoryEmailSender) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+// Messages returns all recorded messages in order sent
+func (m *MemoryEmailSender) Messages() []EmailMessage {
+	return m.Filter(func(EmailMessage) bool { return true })
+}
+
+// Len returns number of recorded messages
+func (m *MemoryEmailSender) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.messages)
+}
+
+// Last returns last recorded message
+func (m *MemoryEmailSender) Last() (EmailMessage, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.messages) == 0 {
+		return EmailMessage{}, false
+	}
+
+	return m.messages[len(m.messages)-1], true
+}
+
+// SentTo returns recorded messages where email is a recipient,
+// ignoring case
+func (m *MemoryEmailSender) SentTo(email string) []EmailMessage {
+	return m.Filter(func(msg EmailMessage) bool {
+		for _, to := range emailRecipients(msg) {
+			if strings.EqualFold(to, email) {
+				return true
+			}
 		}
 
-		if response.StatusCode >= 200 && response.StatusCode < 300 {
-			log.Printf("Email sent successfully! Status: %d", response.StatusCode)
-			return nil
+		return false
+	})
+}
+
+// WithSubject returns recorded messages with subject
+func (m *MemoryEmailSender) WithSubject(subject string) []EmailMessage {
+	return m.Filter(func(msg EmailMessage) bool {
+		return msg.Subject == subject
+	})
+}
+
+// Filter returns recorded messages fn returns true for
+func (m *MemoryEmailSender) Filter(fn func(EmailMessage) bool) []EmailMessage {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	msgs := make([]EmailMessage, 0)
+
+	for _, msg := range m.messages {
+		if fn(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
+
+// Reset removes all recorded messages
+func (m *MemoryEmailSender) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// emailRecipients returns email addresses msg is delivered to
+func emailRecipients(msg EmailMessage) []string {
+	recipients := make([]string, 0, len(msg.To)+len(msg.CC)+len(msg.BCC))
+
+	for _, addresses := range [][]EmailAddress{msg.To, msg.CC, msg.BCC} {
+		for _, address := range addresses {
+			recipients = append(recipients, address.Email)
 		}
+	}
+
+	return recipients
+}
 
-		return fmt.Errorf("SendGrid API error: status %d, body %s", response.StatusCode, response.Body)
+// validateEmailMessage checks that msg has sender and recipient
+func validateEmailMessage(msg EmailMessage) error {
+	if msg.From.Email == "" {
+		return errors.New("webutil: email has no sender")
+	}
+	if len(msg.To) == 0 {
+		return errors.New("webutil: email has no recipients")
 	}
 
 	return nil
 }
+
+// attachmentContentType returns content type of attachment
+func attachmentContentType(attachment EmailAttachment) string {
+	if attachment.ContentType != "" {
+		return attachment.ContentType
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(attachment.Filename)); contentType != "" {
+		return contentType
+	}
+
+	return "application/octet-stream"
+}
+
+// newSendGridMessage converts msg to SendGrid message
+func newSendGridMessage(msg EmailMessage) (*mail.SGMailV3, error) {
+	if err := validateEmailMessage(msg); err != nil {
+		return nil, err
+	}
+
+	m := mail.NewV3Mail()
+	m.SetFrom(mail.NewEmail(msg.From.Name, msg.From.Email))
+	m.Subject = msg.Subject
+
+	if msg.ReplyTo.Email != "" {
+		m.SetReplyTo(mail.NewEmail(msg.ReplyTo.Name, msg.ReplyTo.Email))
+	}
+
+	p := mail.NewPersonalization()
+
+	for _, to := range msg.To {
+		p.AddTos(mail.NewEmail(to.Name, to.Email))
+	}
+	for _, cc := range msg.CC {
+		p.AddCCs(mail.NewEmail(cc.Name, cc.Email))
+	}
+	for _, bcc := range msg.BCC {
+		p.AddBCCs(mail.NewEmail(bcc.Name, bcc.Email))
+	}
+
+	m.AddPersonalizations(p)
+
+	// SendGrid requires plain text before html
+	if msg.PlainText != "" {
+		m.AddContent(mail.NewContent("text/plain", msg.PlainText))
+	}
+	if msg.HTML != "" {
+		m.AddContent(mail.NewContent("text/html", msg.HTML))
+	}
+
+	for key, value := range msg.Headers {
+		m.SetHeader(key, value)
+	}
+
+	if len(msg.Categories) > 0 {
+		m.AddCategories(msg.Categories...)
+	}
+
+	for _, attachment := range msg.Attachments {
+		a := mail.NewAttachment().
+			SetContent(base64.StdEncoding.EncodeToString(attachment.Content)).
+			SetType(attachmentContentType(attachment)).
+			SetFilename(attachment.Filename)
+
+		if attachment.ContentID != "" {
+			a.SetDisposition("inline").SetContentID(attachment.ContentID)
+		} else {
+			a.SetDisposition("attachment")
+		}
+
+		m.AddAttachment(a)
+	}
+
+	return m, nil
+}
+
+// newMailMessage converts msg to MIME message
+func newMailMessage(msg EmailMessage) (*gomail.Message, error) {
+	if err := validateEmailMessage(msg); err != nil {
+		return nil, err
+	}
+
+	m := gomail.NewMessage()
+
+	for key, value := range msg.Headers {
+		m.SetHeader(key, value)
+	}
+
+	m.SetAddressHeader("From", msg.From.Email, msg.From.Name)
+	m.SetHeader("Subject", msg.Subject)
+
+	if msg.ReplyTo.Email != "" {
+		m.SetAddressHeader("Reply-To", msg.ReplyTo.Email, msg.ReplyTo.Name)
+	}
+
+	// Bcc recipients are intentionally left out of headers
+	for field, addresses := range map[string][]EmailAddress{"To": msg.To, "Cc": msg.CC} {
+		if len(addresses) == 0 {
+			continue
+		}
+
+		values := make([]string, 0, len(addresses))
+
+		for _, address := range addresses {
+			values = append(values, m.FormatAddress(address.Email, address.Name))
+		}
+
+		m.SetHeader(field, values...)
+	}
+
+	switch {
+	case msg.PlainText != "" && msg.HTML != "":
+		m.SetBody("text/plain", msg.PlainText)
+		m.AddAlternative("text/html", msg.HTML)
+	case msg.HTML != "":
+		m.SetBody("text/html", msg.HTML)
+	default:
+		m.SetBody("text/plain", msg.PlainText)
+	}
+
+	for _, attachment := range msg.Attachments {
+		content := attachment.Content
+		header := map[string][]string{
+			"Content-Type": {attachmentContentType(attachment)},
+		}
+
+		if attachment.ContentID != "" {
+			header["Content-ID"] = []string{"<" + attachment.ContentID + ">"}
+		}
+
+		settings := []gomail.FileSetting{
+			gomail.SetHeader(header),
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(content)
+				return err
+			}),
+		}
+
+		if attachment.ContentID != "" {
+			m.Embed(attachment.Filename, settings...)
+		} else {
+			m.Attach(attachment.Filename, settings...)
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,429 @@
+package webutil
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTPServer records messages sent over plain text smtp
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     []string
+	rcpts    []string
+	data     []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	s := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	write("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimSpace(line)
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO":
+			write("250-localhost")
+			write("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = append(s.auth, line)
+			s.mu.Unlock()
+			write("235 authenticated")
+		case "RCPT":
+			if strings.Contains(line, "reject@") {
+				write("550 rejected")
+				continue
+			}
+
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, line)
+			s.mu.Unlock()
+			write("250 ok")
+		case "DATA":
+			write("354 go ahead")
+
+			var data strings.Builder
+
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+
+				data.WriteString(dataLine)
+			}
+
+			s.mu.Lock()
+			s.data = append(s.data, data.String())
+			s.mu.Unlock()
+			write("250 queued")
+		case "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("250 ok")
+		}
+	}
+}
+
+func TestSMTPSenderUnitTest(t *testing.T) {
+	server := newFakeSMTPServer(t)
+
+	sender := NewSMTPSender(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "user",
+		Password: "pass",
+		Security: SMTP_SECURITY_NONE,
+	})
+
+	msgs := []EmailMessage{
+		{
+			From:      EmailAddress{Email: "from@example.com", Name: "From"},
+			To:        []EmailAddress{{Email: "to@example.com"}},
+			CC:        []EmailAddress{{Email: "cc@example.com"}},
+			BCC:       []EmailAddress{{Email: "bcc@example.com"}},
+			ReplyTo:   EmailAddress{Email: "reply@example.com"},
+			Subject:   "Hello",
+			PlainText: "plain body",
+			HTML:      "<p>html body</p>",
+			Headers:   map[string]string{"X-Custom": "custom"},
+			Attachments: []EmailAttachment{
+				{
+					Content:  []byte("file content"),
+					Filename: "file.txt",
+				},
+			},
+		},
+		{
+			From:      EmailAddress{Email: "from@example.com"},
+			To:        []EmailAddress{{Email: "other@example.com"}},
+			Subject:   "Second",
+			PlainText: "second body",
+		},
+	}
+
+	if err := sender.SendEmail(msgs...); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if len(server.auth) != 1 {
+		t.Errorf("should authenticate once; got %d\n", len(server.auth))
+	}
+	if len(server.data) != 2 {
+		t.Fatalf("should have 2 messages; got %d\n", len(server.data))
+	}
+	if len(server.rcpts) != 4 || !strings.Contains(server.rcpts[2], "bcc@example.com") {
+		t.Errorf("should have recipients; got %v\n", server.rcpts)
+	}
+
+	if strings.Contains(server.data[0], "bcc@example.com") {
+		t.Errorf("should not have bcc header\n")
+	}
+
+	for _, expected := range []string{
+		"Subject: Hello",
+		`From: "From" <from@example.com>`,
+		"text/plain",
+		"text/html",
+		`filename="file.txt"`,
+		"Cc: cc@example.com",
+		"Reply-To: reply@example.com",
+		"X-Custom: custom",
+	} {
+		if !strings.Contains(server.data[0], expected) {
+			t.Errorf("should contain %q; got %s\n", expected, server.data[0])
+		}
+	}
+
+	// ---------------------------------------------------------------
+
+	sender = NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port()})
+
+	if err := sender.SendEmail(msgs[1]); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("should have STARTTLS error; got %v\n", err)
+	}
+
+	sender = NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Security: SMTP_SECURITY_NONE})
+
+	if err := sender.SendEmail(EmailMessage{From: EmailAddress{Email: "from@example.com"}}); err == nil {
+		t.Errorf("should have recipients error\n")
+	}
+
+	// ---------------------------------------------------------------
+
+	// Failed message shouldn't stop the rest from being sent
+	partial := newFakeSMTPServer(t)
+	sender = NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: partial.port(), Security: SMTP_SECURITY_NONE})
+
+	var sendErr *EmailSendError
+
+	err := sender.SendEmail(
+		msgs[1],
+		EmailMessage{From: msgs[1].From, To: []EmailAddress{{Email: "reject@example.com"}}, Subject: "Rejected", PlainText: "body"},
+		msgs[1],
+	)
+	if !errors.As(err, &sendErr) {
+		t.Fatalf("should have *EmailSendError; got %v\n", err)
+	}
+	if len(sendErr.Results) != 3 || sendErr.Results[0].Err != nil || sendErr.Results[1].Err == nil || sendErr.Results[2].Err != nil {
+		t.Errorf("should only fail second message; got %+v\n", sendErr.Results)
+	}
+
+	partial.mu.Lock()
+	defer partial.mu.Unlock()
+
+	if len(partial.data) != 2 {
+		t.Errorf("should have 2 messages; got %d\n", len(partial.data))
+	}
+}
+
+func TestFileEmailSenderUnitTest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender := NewFileEmailSender(dir)
+
+	from := EmailAddress{Email: "from@example.com"}
+	to := []EmailAddress{{Email: "to@example.com"}}
+
+	err := sender.SendEmail(
+		EmailMessage{From: from, To: to, Subject: "First", HTML: "<p>hi</p>"},
+		EmailMessage{From: from, To: to, Subject: "Second"},
+	)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+
+	if len(files) != 2 {
+		t.Fatalf("should have 2 files; got %d\n", len(files))
+	}
+
+	found := false
+
+	for _, file := range files {
+		b, _ := os.ReadFile(file)
+
+		if strings.Contains(string(b), "Subject: First") && strings.Contains(string(b), "text/html") {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("should have file with first message\n")
+	}
+
+	if err = sender.SendEmail(EmailMessage{From: from}); err == nil {
+		t.Errorf("should have recipients error\n")
+	}
+}
+
+func TestMemoryEmailSenderUnitTest(t *testing.T) {
+	sender := NewMemoryEmailSender()
+
+	if _, ok := sender.Last(); ok {
+		t.Errorf("should not have last message\n")
+	}
+
+	err := sender.SendEmail(
+		EmailMessage{To: []EmailAddress{{Email: "foo@example.com"}}, Subject: "Welcome"},
+		EmailMessage{To: []EmailAddress{{Email: "bar@example.com"}}, Subject: "Welcome"},
+		EmailMessage{BCC: []EmailAddress{{Email: "Foo@Example.com"}}, Subject: "Reset"},
+	)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if sender.Len() != 3 || len(sender.Messages()) != 3 {
+		t.Errorf("should have 3 messages; got %d\n", sender.Len())
+	}
+	if msgs := sender.SentTo("foo@example.com"); len(msgs) != 2 {
+		t.Errorf("should have 2 messages to foo; got %d\n", len(msgs))
+	}
+	if msgs := sender.WithSubject("Welcome"); len(msgs) != 2 {
+		t.Errorf("should have 2 welcome messages; got %d\n", len(msgs))
+	}
+	if last, _ := sender.Last(); last.Subject != "Reset" {
+		t.Errorf("should have last reset message; got %s\n", last.Subject)
+	}
+
+	sendErr := errors.New("send error")
+	sender.SetError(sendErr)
+
+	if err = sender.SendEmail(EmailMessage{}); !errors.Is(err, sendErr) {
+		t.Errorf("should have send error; got %v\n", err)
+	}
+	if sender.Len() != 3 {
+		t.Errorf("should not record failed message; got %d\n", sender.Len())
+	}
+
+	sender.SetError(nil)
+	sender.Reset()
+
+	if sender.Len() != 0 {
+		t.Errorf("should have no messages; got %d\n", sender.Len())
+	}
+}
+
+func TestSendGridUnitTest(t *testing.T) {
+	var bodies []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/mail/send" || r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body map[string]any
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		bodies = append(bodies, body)
+
+		if body["subject"] == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"message":"bad"}]}`))
+			return
+		}
+
+		w.Header().Set("X-Message-Id", "id-"+body["subject"].(string))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := NewSendGridWithConfig(SendGridConfig{APIKey: "key", Host: server.URL})
+
+	msg := EmailMessage{
+		From:       EmailAddress{Email: "from@example.com", Name: "From"},
+		To:         []EmailAddress{{Email: "to@example.com"}, {Email: "to2@example.com"}},
+		CC:         []EmailAddress{{Email: "cc@example.com"}},
+		BCC:        []EmailAddress{{Email: "bcc@example.com"}},
+		ReplyTo:    EmailAddress{Email: "reply@example.com"},
+		Subject:    "first",
+		PlainText:  "plain",
+		HTML:       "<p>html</p>",
+		Headers:    map[string]string{"X-Custom": "custom"},
+		Categories: []string{"welcome"},
+		Attachments: []EmailAttachment{
+			{Filename: "file.pdf", Content: []byte("pdf")},
+			{Filename: "logo.png", Content: []byte("png"), ContentID: "logo"},
+		},
+	}
+
+	second := msg
+	second.Subject = "second"
+
+	if err := sender.SendEmail(msg, second); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("should send every message; got %d\n", len(bodies))
+	}
+
+	b, _ := json.Marshal(bodies[0])
+	body := string(b)
+
+	for _, expected := range []string{
+		`"bcc":[{"email":"bcc@example.com"}]`,
+		`"cc":[{"email":"cc@example.com"}]`,
+		`"reply_to":{"email":"reply@example.com"}`,
+		`"categories":["welcome"]`,
+		`"X-Custom":"custom"`,
+		`"content":"` + base64.StdEncoding.EncodeToString([]byte("pdf")) + `"`,
+		`"type":"application/pdf"`,
+		`"content_id":"logo"`,
+		`"disposition":"inline"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("should contain %s; got %s\n", expected, body)
+		}
+	}
+
+	// ---------------------------------------------------------------
+
+	failed := msg
+	failed.Subject = "fail"
+
+	results := sender.SendEmailResults(msg, failed, second, EmailMessage{})
+
+	if len(results) != 4 {
+		t.Fatalf("should have 4 results; got %d\n", len(results))
+	}
+	if results[0].Err != nil || results[0].MessageID != "id-first" {
+		t.Errorf("should have first message id; got %+v\n", results[0])
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "status 400") {
+		t.Errorf("should have api error; got %v\n", results[1].Err)
+	}
+	if results[2].Err != nil || results[2].Index != 2 {
+		t.Errorf("should send message after failure; got %+v\n", results[2])
+	}
+	if results[3].Err == nil {
+		t.Errorf("should have invalid message error\n")
+	}
+
+	var sendErr *EmailSendError
+
+	if err := sender.SendEmail(msg, failed); !errors.As(err, &sendErr) {
+		t.Errorf("should have *EmailSendError; got %v\n", err)
+	} else if len(sendErr.Results) != 2 || len(sendErr.Unwrap()) != 1 {
+		t.Errorf("should have 1 of 2 failed; got %s\n", sendErr.Error())
+	}
+}
@@ -0,0 +1,347 @@
+package webutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// EmailLimiterConfig is config struct used in the initialization
+// of *EmailLimiter
+type EmailLimiterConfig struct {
+	// RecipientLimit is max number of messages each recipient
+	// can be sent within RecipientWindow
+	//
+	// Default: 0 (no limit)
+	RecipientLimit int
+
+	// RecipientWindow is sliding window of RecipientLimit
+	//
+	// Default: 1 hour
+	RecipientWindow time.Duration
+
+	// GlobalLimit is max number of messages that can be
+	// sent within GlobalWindow
+	//
+	// Default: 0 (no limit)
+	GlobalLimit int
+
+	// GlobalWindow is sliding window of GlobalLimit
+	//
+	// Default: 1 hour
+	GlobalWindow time.Duration
+
+	// DedupeWindow is how long a message with the same recipients,
+	// subject and content as a sent message is blocked
+	//
+	// Default: 0 (no dedupe)
+	DedupeWindow time.Duration
+}
+
+// EmailLimitError is returned for messages blocked by EmailLimiter
+type EmailLimitError struct {
+	// Reason is one of EMAIL_LIMIT_RECIPIENT, EMAIL_LIMIT_GLOBAL
+	// or EMAIL_LIMIT_DUPLICATE
+	Reason string
+
+	// Recipient is recipient that reached limit for EMAIL_LIMIT_RECIPIENT
+	Recipient string
+
+	// RetryAfter is how long until message would be allowed
+	RetryAfter time.Duration
+}
+
+// EmailLimiter implements EmailSender by wrapping another EmailSender
+// and blocking messages that exceed rate limits or that are duplicates
+// of recently sent messages
+//
+// Limits are kept in memory so they apply per process
+type EmailLimiter struct {
+	mu         sync.Mutex
+	sender     EmailSender
+	config     EmailLimiterConfig
+	recipients map[string][]time.Time
+	global     []time.Time
+	dedupe     map[string]time.Time
+	lastSweep  time.Time
+	now        func() time.Time
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewEmailLimiter returns *EmailLimiter that sends allowed messages with sender
+func NewEmailLimiter(sender EmailSender, config EmailLimiterConfig) *EmailLimiter {
+	if config.RecipientWindow <= 0 {
+		config.RecipientWindow = time.Hour
+	}
+	if config.GlobalWindow <= 0 {
+		config.GlobalWindow = time.Hour
+	}
+
+	return &EmailLimiter{
+		sender:     sender,
+		config:     config,
+		recipients: make(map[string][]time.Time),
+		dedupe:     make(map[string]time.Time),
+		now:        time.Now,
+	}
+}
+
+// EmailDedupeKey returns key of msg derived from its recipients,
+// subject and hash of its content
+func EmailDedupeKey(msg EmailMessage) string {
+	recipients := emailRecipients(msg)
+
+	for i := range recipients {
+		recipients[i] = strings.ToLower(recipients[i])
+	}
+
+	sort.Strings(recipients)
+
+	content := sha256.New()
+	content.Write([]byte(msg.PlainText))
+	content.Write([]byte{0})
+	content.Write([]byte(msg.HTML))
+
+	for _, attachment := range msg.Attachments {
+		content.Write([]byte{0})
+		content.Write([]byte(attachment.Filename))
+		content.Write([]byte{0})
+		content.Write(attachment.Content)
+	}
+
+	key := sha256.Sum256([]byte(
+		strings.Join(recipients, ",") + "\x00" + msg.Subject + "\x00" + hex.EncodeToString(content.Sum(nil)),
+	))
+
+	return hex.EncodeToString(key[:])
+}
+
+// pruneWindow removes times that are outside of window
+func pruneWindow(times []time.Time, now time.Time, window time.Duration) []time.Time {
+	i := 0
+
+	for i < len(times) && !times[i].After(now.Add(-window)) {
+		i++
+	}
+
+	return times[i:]
+}
+
+// removeWindowTime removes latest occurrence of t from times
+func removeWindowTime(times []time.Time, t time.Time) []time.Time {
+	for i := len(times) - 1; i >= 0; i-- {
+		if times[i].Equal(t) {
+			return append(times[:i], times[i+1:]...)
+		}
+	}
+
+	return times
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+func (e *EmailLimitError) Error() string {
+	switch e.Reason {
+	case EMAIL_LIMIT_RECIPIENT:
+		return fmt.Sprintf("webutil: email rate limit reached for %s, retry after %s", e.Recipient, e.RetryAfter)
+	case EMAIL_LIMIT_GLOBAL:
+		return fmt.Sprintf("webutil: global email rate limit reached, retry after %s", e.RetryAfter)
+	default:
+		return fmt.Sprintf("webutil: duplicate email, retry after %s", e.RetryAfter)
+	}
+}
+
+// SendEmail sends messages that are allowed with the wrapped EmailSender
+//
+// A single blocked message returns *EmailLimitError and multiple messages
+// with any blocked return *EmailSendError with result of each message,
+// where allowed messages are still sent
+//
+// Limits are only given back for messages the wrapped sender reports as
+// failed through *EmailSendError, any other error keeps them
+func (e *EmailLimiter) SendEmail(msgs ...EmailMessage) error {
+	results := make([]EmailResult, len(msgs))
+	allowed := make([]EmailMessage, 0, len(msgs))
+	allowedIdx := make([]int, 0, len(msgs))
+	keys := make([]string, len(msgs))
+
+	e.mu.Lock()
+	now := e.now()
+	e.sweep(now)
+
+	for i, msg := range msgs {
+		results[i].Index = i
+
+		if keys[i], results[i].Err = e.allow(msg, now); results[i].Err == nil {
+			allowed = append(allowed, msg)
+			allowedIdx = append(allowedIdx, i)
+		}
+	}
+
+	e.mu.Unlock()
+
+	if len(allowed) > 0 {
+		if err := e.sender.SendEmail(allowed...); err != nil {
+			var sendErr *EmailSendError
+
+			// Without per message results it's unknown which messages were
+			// delivered so limits are kept to avoid sending any twice
+			confirmed := errors.As(err, &sendErr)
+
+			for i, idx := range allowedIdx {
+				results[idx].Err = err
+
+				if !confirmed {
+					continue
+				}
+
+				results[idx].Err = nil
+
+				for _, result := range sendErr.Results {
+					if result.Index == i {
+						results[idx].Err = result.Err
+						results[idx].MessageID = result.MessageID
+					}
+				}
+
+				// Confirmed failed messages shouldn't use up limits
+				// or block retries as duplicates
+				if results[idx].Err != nil {
+					e.release(msgs[idx], keys[idx], now)
+				}
+			}
+		}
+	}
+
+	if len(msgs) == 1 {
+		return results[0].Err
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			return &EmailSendError{Results: results}
+		}
+	}
+
+	return nil
+}
+
+// allow checks limits of msg and records msg if allowed, returning
+// dedupe key of msg
+func (e *EmailLimiter) allow(msg EmailMessage, now time.Time) (string, error) {
+	var key string
+
+	if e.config.DedupeWindow > 0 {
+		key = EmailDedupeKey(msg)
+
+		if sentAt, ok := e.dedupe[key]; ok && now.Before(sentAt.Add(e.config.DedupeWindow)) {
+			return "", &EmailLimitError{
+				Reason:     EMAIL_LIMIT_DUPLICATE,
+				RetryAfter: sentAt.Add(e.config.DedupeWindow).Sub(now),
+			}
+		}
+	}
+
+	if e.config.GlobalLimit > 0 {
+		e.global = pruneWindow(e.global, now, e.config.GlobalWindow)
+
+		if len(e.global) >= e.config.GlobalLimit {
+			return "", &EmailLimitError{
+				Reason:     EMAIL_LIMIT_GLOBAL,
+				RetryAfter: e.global[0].Add(e.config.GlobalWindow).Sub(now),
+			}
+		}
+	}
+
+	recipients := emailRecipients(msg)
+
+	if e.config.RecipientLimit > 0 {
+		for i, recipient := range recipients {
+			recipients[i] = strings.ToLower(recipient)
+			times := pruneWindow(e.recipients[recipients[i]], now, e.config.RecipientWindow)
+			e.recipients[recipients[i]] = times
+
+			if len(times) >= e.config.RecipientLimit {
+				return "", &EmailLimitError{
+					Reason:     EMAIL_LIMIT_RECIPIENT,
+					Recipient:  recipient,
+					RetryAfter: times[0].Add(e.config.RecipientWindow).Sub(now),
+				}
+			}
+		}
+
+		for _, recipient := range recipients {
+			e.recipients[recipient] = append(e.recipients[recipient], now)
+		}
+	}
+
+	if e.config.GlobalLimit > 0 {
+		e.global = append(e.global, now)
+	}
+	if key != "" {
+		e.dedupe[key] = now
+	}
+
+	return key, nil
+}
+
+// release removes what allow recorded for msg at now so
+// message can be sent again
+func (e *EmailLimiter) release(msg EmailMessage, key string, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.config.RecipientLimit > 0 {
+		for _, recipient := range emailRecipients(msg) {
+			recipient = strings.ToLower(recipient)
+			e.recipients[recipient] = removeWindowTime(e.recipients[recipient], now)
+		}
+	}
+
+	if e.config.GlobalLimit > 0 {
+		e.global = removeWindowTime(e.global, now)
+	}
+
+	if sentAt, ok := e.dedupe[key]; ok && sentAt.Equal(now) {
+		delete(e.dedupe, key)
+	}
+}
+
+// sweep removes expired entries at most once a minute so
+// recipients that stop receiving messages don't leak memory
+func (e *EmailLimiter) sweep(now time.Time) {
+	if now.Sub(e.lastSweep) < time.Minute {
+		return
+	}
+
+	e.lastSweep = now
+
+	for recipient, times := range e.recipients {
+		if times = pruneWindow(times, now, e.config.RecipientWindow); len(times) == 0 {
+			delete(e.recipients, recipient)
+		} else {
+			e.recipients[recipient] = times
+		}
+	}
+
+	for key, sentAt := range e.dedupe {
+		if !now.Before(sentAt.Add(e.config.DedupeWindow)) {
+			delete(e.dedupe, key)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package webutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEmailLimiterUnitTest(t *testing.T) {
+	var err error
+	var limitErr *EmailLimitError
+	var sendErr *EmailSendError
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	memory := NewMemoryEmailSender()
+	limiter := NewEmailLimiter(memory, EmailLimiterConfig{
+		RecipientLimit:  2,
+		RecipientWindow: time.Hour,
+		GlobalLimit:     4,
+		GlobalWindow:    time.Minute,
+		DedupeWindow:    10 * time.Minute,
+	})
+	limiter.now = func() time.Time { return now }
+
+	newMsg := func(to, subject, text string) EmailMessage {
+		return EmailMessage{
+			From:      EmailAddress{Email: "noreply@example.com"},
+			To:        []EmailAddress{{Email: to}},
+			Subject:   subject,
+			PlainText: text,
+		}
+	}
+
+	if err = limiter.SendEmail(newMsg("bob@example.com", "Reset", "code 1")); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if err = limiter.SendEmail(newMsg("BOB@example.com", "Reset", "code 1")); !errors.As(err, &limitErr) {
+		t.Fatalf("should have *EmailLimitError; got %v\n", err)
+	}
+	if limitErr.Reason != EMAIL_LIMIT_DUPLICATE || limitErr.RetryAfter != 10*time.Minute {
+		t.Errorf("should have duplicate with 10m retry; got %+v\n", limitErr)
+	}
+
+	if err = limiter.SendEmail(newMsg("bob@example.com", "Reset", "code 2")); err != nil {
+		t.Errorf("should allow different content; got %v\n", err)
+	}
+
+	now = now.Add(5 * time.Minute)
+
+	if err = limiter.SendEmail(newMsg("bob@example.com", "Reset", "code 3")); !errors.As(err, &limitErr) {
+		t.Fatalf("should have *EmailLimitError; got %v\n", err)
+	}
+	if limitErr.Reason != EMAIL_LIMIT_RECIPIENT || limitErr.Recipient != "bob@example.com" || limitErr.RetryAfter != 55*time.Minute {
+		t.Errorf("should have recipient limit with 55m retry; got %+v\n", limitErr)
+	}
+
+	if memory.Len() != 2 {
+		t.Errorf("should have sent 2 messages; got %d\n", memory.Len())
+	}
+
+	// ---------------------------------------------------------------
+
+	// Batches send allowed messages and report blocked ones
+	err = limiter.SendEmail(
+		newMsg("a@example.com", "Hi", "1"),
+		newMsg("a@example.com", "Hi", "1"),
+		newMsg("b@example.com", "Hi", "1"),
+		newMsg("c@example.com", "Hi", "1"),
+		newMsg("d@example.com", "Hi", "1"),
+		newMsg("e@example.com", "Hi", "1"),
+	)
+	if !errors.As(err, &sendErr) {
+		t.Fatalf("should have *EmailSendError; got %v\n", err)
+	}
+
+	for i, reason := range []string{"", EMAIL_LIMIT_DUPLICATE, "", "", "", EMAIL_LIMIT_GLOBAL} {
+		if reason == "" {
+			if sendErr.Results[i].Err != nil {
+				t.Errorf("should send message %d; got %v\n", i, sendErr.Results[i].Err)
+			}
+		} else if !errors.As(sendErr.Results[i].Err, &limitErr) || limitErr.Reason != reason {
+			t.Errorf("should block message %d with %s; got %v\n", i, reason, sendErr.Results[i].Err)
+		}
+	}
+
+	if memory.Len() != 6 {
+		t.Errorf("should have sent 6 messages; got %d\n", memory.Len())
+	}
+
+	now = now.Add(time.Minute)
+
+	if err = limiter.SendEmail(newMsg("e@example.com", "Hi", "1")); err != nil {
+		t.Errorf("should allow after global window; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	// Untyped errors don't say which messages were delivered
+	// so limits are kept
+	failErr := errors.New("send failed")
+	memory.SetError(failErr)
+
+	if err = limiter.SendEmail(newMsg("f@example.com", "Hi", "1")); !errors.Is(err, failErr) {
+		t.Errorf("should have sender error; got %v\n", err)
+	}
+
+	memory.SetError(nil)
+
+	if err = limiter.SendEmail(newMsg("f@example.com", "Hi", "1")); !errors.As(err, &limitErr) || limitErr.Reason != EMAIL_LIMIT_DUPLICATE {
+		t.Errorf("should keep dedupe key after untyped error; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	// Sender that delivers first message before failing the second
+	partialErr := errors.New("connection reset")
+	results := func(msgs ...EmailMessage) []EmailResult {
+		return []EmailResult{{Index: 0, MessageID: "1"}, {Index: 1, Err: partialErr}}
+	}
+
+	typedLimiter := NewEmailLimiter(emailSenderFunc(func(msgs ...EmailMessage) error {
+		return &EmailSendError{Results: results(msgs...)}
+	}), EmailLimiterConfig{RecipientLimit: 1, DedupeWindow: time.Hour})
+	typedLimiter.now = func() time.Time { return now }
+
+	if err = typedLimiter.SendEmail(newMsg("h@example.com", "Hi", "1"), newMsg("i@example.com", "Hi", "1")); !errors.As(err, &sendErr) {
+		t.Fatalf("should have *EmailSendError; got %v\n", err)
+	}
+	if sendErr.Results[0].Err != nil || sendErr.Results[0].MessageID != "1" || !errors.Is(sendErr.Results[1].Err, partialErr) {
+		t.Errorf("should map results of wrapped sender; got %+v\n", sendErr.Results)
+	}
+	if len(typedLimiter.recipients["h@example.com"]) != 1 || len(typedLimiter.dedupe) != 1 {
+		t.Errorf("should keep limits of delivered message\n")
+	}
+	if len(typedLimiter.recipients["i@example.com"]) != 0 {
+		t.Errorf("should release limits of failed message\n")
+	}
+
+	untypedLimiter := NewEmailLimiter(emailSenderFunc(func(msgs ...EmailMessage) error {
+		return partialErr
+	}), EmailLimiterConfig{RecipientLimit: 1, DedupeWindow: time.Hour})
+	untypedLimiter.now = func() time.Time { return now }
+
+	if err = untypedLimiter.SendEmail(newMsg("h@example.com", "Hi", "1"), newMsg("i@example.com", "Hi", "1")); !errors.As(err, &sendErr) {
+		t.Fatalf("should have *EmailSendError; got %v\n", err)
+	}
+	if len(untypedLimiter.recipients["h@example.com"]) != 1 || len(untypedLimiter.recipients["i@example.com"]) != 1 || len(untypedLimiter.dedupe) != 2 {
+		t.Errorf("should keep limits when delivery is unknown\n")
+	}
+
+	// ---------------------------------------------------------------
+
+	// Limits without a window use the default window rather than
+	// being disabled
+	defaultLimiter := NewEmailLimiter(NewMemoryEmailSender(), EmailLimiterConfig{RecipientLimit: 1, GlobalLimit: 5})
+	defaultLimiter.now = func() time.Time { return now }
+
+	if err = defaultLimiter.SendEmail(newMsg("g@example.com", "Hi", "1")); err != nil {
+		t.Errorf("should not have error; got %v\n", err)
+	}
+	if err = defaultLimiter.SendEmail(newMsg("g@example.com", "Hi", "2")); !errors.As(err, &limitErr) || limitErr.RetryAfter != time.Hour {
+		t.Errorf("should have recipient limit with 1h retry; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	now = now.Add(2 * time.Hour)
+	limiter.sweep(now)
+
+	if len(limiter.recipients) != 0 || len(limiter.dedupe) != 0 {
+		t.Errorf("should remove expired entries; got %d recipients and %d keys\n", len(limiter.recipients), len(limiter.dedupe))
+	}
+
+	if EmailDedupeKey(newMsg("x@example.com", "a", "b")) == EmailDedupeKey(newMsg("x@example.com", "a", "c")) {
+		t.Errorf("should have different keys for different content\n")
+	}
+}
@@ -0,0 +1,479 @@
+package webutil
+
+import (
+	"bytes"
+	"html"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// EmailTemplatesConfig is config struct used in the initialization
+// of *EmailTemplates
+type EmailTemplatesConfig struct {
+	// Dir is the directory within the fs.FS that contains templates
+	//
+	// Templates are "<name>.html" and "<name>.txt" files within Dir,
+	// layouts are within "<Dir>/layouts" and partials are within
+	// "<Dir>/partials" where partials are referenced by path such as
+	// {{template "partials/button.html" .}}
+	//
+	// Default: "."
+	Dir string
+
+	// Layout is name of layout without extension, such as "base", that
+	// templates are rendered within where the layout renders the template
+	// with {{template "content" .}} and templates {{define "content"}}
+	//
+	// Templates of an extension with no layout file are rendered on their own
+	//
+	// Default: ""
+	Layout string
+
+	// Funcs are added to both html and text templates
+	Funcs map[string]any
+
+	// CSSInliner is applied to rendered html, such as InlineCSS,
+	// as many email clients ignore <style> blocks
+	//
+	// Default: nil
+	CSSInliner func(html string) (string, error)
+}
+
+// RenderedEmail is result of rendering email template
+type RenderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// EmailTemplates renders html and text email templates loaded from fs.FS
+//
+// Subject is rendered from {{define "subject"}} within the text template,
+// or the html template if there is no text template
+type EmailTemplates struct {
+	config    EmailTemplatesConfig
+	templates map[string]*emailTemplate
+}
+
+type emailTemplate struct {
+	html     *htmltemplate.Template
+	htmlName string
+	text     *texttemplate.Template
+	textName string
+}
+
+// cssRule is single simple selector with its declarations
+type cssRule struct {
+	tag         string
+	id          string
+	classes     []string
+	decls       string
+	specificity int
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	cssStyleBlockRegex    = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>`)
+	cssCommentRegex       = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssRuleRegex          = regexp.MustCompile(`([^{}]+)\{([^{}]*)\}`)
+	cssSimpleSelector     = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?((?:[.#][-_a-zA-Z0-9]+)*)$`)
+	cssSelectorPartRegex  = regexp.MustCompile(`[.#][-_a-zA-Z0-9]+`)
+	htmlStartTagRegex     = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)((?:\s[^<>]*?)?)(/?)>`)
+	htmlAttrRegex         = regexp.MustCompile(`([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	htmlStyleAttrRegex    = regexp.MustCompile(`(?i)\sstyle\s*=\s*(?:"[^"]*"|'[^']*')`)
+	htmlInlineSkipElement = map[string]bool{"style": true, "script": true, "head": true, "title": true, "meta": true, "link": true}
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewEmailTemplates returns *EmailTemplates with every template
+// within config.Dir of fsys parsed
+//
+// Will return error if any template, layout or partial fails to parse
+func NewEmailTemplates(fsys fs.FS, config EmailTemplatesConfig) (*EmailTemplates, error) {
+	if config.Dir == "" {
+		config.Dir = "."
+	}
+
+	htmlBase := htmltemplate.New("").Funcs(htmltemplate.FuncMap(config.Funcs))
+	textBase := texttemplate.New("").Funcs(texttemplate.FuncMap(config.Funcs))
+
+	for _, dir := range []string{"layouts", "partials"} {
+		files, err := readTemplateDir(fsys, path.Join(config.Dir, dir))
+		if err != nil {
+			return nil, err
+		}
+
+		for name, content := range files {
+			name = path.Join(dir, name)
+
+			if strings.HasSuffix(name, ".html") {
+				_, err = htmlBase.New(name).Parse(content)
+			} else {
+				_, err = textBase.New(name).Parse(content)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "webutil: failed to parse email template %q", name)
+			}
+		}
+	}
+
+	files, err := readTemplateDir(fsys, config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*emailTemplate)
+
+	for fileName, content := range files {
+		ext := path.Ext(fileName)
+		name := strings.TrimSuffix(fileName, ext)
+
+		tmpl, ok := templates[name]
+		if !ok {
+			tmpl = &emailTemplate{}
+			templates[name] = tmpl
+		}
+
+		if ext == ".html" {
+			t, err := htmlBase.Clone()
+			if err == nil {
+				_, err = t.New(fileName).Parse(content)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "webutil: failed to parse email template %q", fileName)
+			}
+
+			tmpl.html = t
+			tmpl.htmlName = templateEntry(t.Lookup, fileName, config.Layout, ext)
+		} else {
+			t, err := textBase.Clone()
+			if err == nil {
+				_, err = t.New(fileName).Parse(content)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "webutil: failed to parse email template %q", fileName)
+			}
+
+			tmpl.text = t
+			tmpl.textName = templateEntry(t.Lookup, fileName, config.Layout, ext)
+		}
+	}
+
+	return &EmailTemplates{config: config, templates: templates}, nil
+}
+
+// readTemplateDir returns content of ".html" and ".txt" files within dir
+// by file name, where dir not existing returns no files
+func readTemplateDir(fsys fs.FS, dir string) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errors.WithStack(err)
+	}
+
+	files := make(map[string]string)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		if ext := path.Ext(entry.Name()); ext != ".html" && ext != ".txt" {
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		files[entry.Name()] = string(content)
+	}
+
+	return files, nil
+}
+
+// templateEntry returns name of template to execute which is the
+// layout if it exists else the template itself
+func templateEntry[T any](lookup func(string) *T, fileName, layout, ext string) string {
+	if layout != "" {
+		if layoutName := path.Join("layouts", layout+ext); lookup(layoutName) != nil {
+			return layoutName
+		}
+	}
+
+	return fileName
+}
+
+// InlineCSS moves rules of <style> blocks into style attributes of
+// matching elements, as many email clients ignore <style> blocks
+//
+// Only simple selectors such as "p", ".button", "#header" and "a.button"
+// are inlined, ordered by specificity with existing style attributes
+// taking precedence
+//
+// Rules that can't be inlined are kept within their <style> block and
+// blocks containing at-rules, such as @media, are left untouched
+func InlineCSS(input string) (string, error) {
+	var rules []cssRule
+
+	input = cssStyleBlockRegex.ReplaceAllStringFunc(input, func(block string) string {
+		css := cssCommentRegex.ReplaceAllString(cssStyleBlockRegex.FindStringSubmatch(block)[1], "")
+
+		if strings.Contains(css, "@") {
+			return block
+		}
+
+		var remaining strings.Builder
+
+		for _, match := range cssRuleRegex.FindAllStringSubmatch(css, -1) {
+			decls := strings.TrimSuffix(strings.TrimSpace(match[2]), ";")
+			complexSelectors := make([]string, 0)
+
+			for _, selector := range strings.Split(match[1], ",") {
+				selector = strings.TrimSpace(selector)
+
+				if rule, ok := parseCSSSelector(selector); ok {
+					rule.decls = decls
+					rules = append(rules, rule)
+				} else if selector != "" {
+					complexSelectors = append(complexSelectors, selector)
+				}
+			}
+
+			if len(complexSelectors) > 0 {
+				remaining.WriteString(strings.Join(complexSelectors, ", ") + " { " + decls + " }\n")
+			}
+		}
+
+		if remaining.Len() == 0 {
+			return ""
+		}
+
+		return "<style>\n" + remaining.String() + "</style>"
+	})
+
+	if len(rules) == 0 {
+		return input, nil
+	}
+
+	// Stable sort keeps order of appearance for equal specificity
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].specificity < rules[j].specificity
+	})
+
+	return htmlStartTagRegex.ReplaceAllStringFunc(input, func(tag string) string {
+		match := htmlStartTagRegex.FindStringSubmatch(tag)
+		tagName, attrs, selfClose := strings.ToLower(match[1]), match[2], match[3]
+
+		if htmlInlineSkipElement[tagName] {
+			return tag
+		}
+
+		var id, style string
+		var classes []string
+
+		for _, attr := range htmlAttrRegex.FindAllStringSubmatch(attrs, -1) {
+			value := attr[2] + attr[3]
+
+			switch strings.ToLower(attr[1]) {
+			case "id":
+				id = value
+			case "class":
+				classes = strings.Fields(value)
+			case "style":
+				style = html.UnescapeString(value)
+			}
+		}
+
+		decls := make([]string, 0)
+
+		for _, rule := range rules {
+			if rule.matches(tagName, id, classes) {
+				decls = append(decls, rule.decls)
+			}
+		}
+
+		if len(decls) == 0 {
+			return tag
+		}
+
+		if style = strings.TrimSuffix(strings.TrimSpace(style), ";"); style != "" {
+			decls = append(decls, style)
+		}
+
+		style = strings.ReplaceAll(strings.Join(decls, "; "), `"`, "'")
+		attrs = strings.TrimRight(htmlStyleAttrRegex.ReplaceAllString(attrs, ""), " ")
+
+		return "<" + match[1] + attrs + ` style="` + html.EscapeString(style) + `"` + selfClose + ">"
+	}), nil
+}
+
+// parseCSSSelector returns rule of simple selector
+func parseCSSSelector(selector string) (cssRule, bool) {
+	match := cssSimpleSelector.FindStringSubmatch(selector)
+	if match == nil || selector == "" {
+		return cssRule{}, false
+	}
+
+	rule := cssRule{tag: strings.ToLower(match[1])}
+
+	if rule.tag != "" {
+		rule.specificity++
+	}
+
+	for _, part := range cssSelectorPartRegex.FindAllString(match[2], -1) {
+		if part[0] == '#' {
+			if rule.id != "" {
+				return cssRule{}, false
+			}
+
+			rule.id = part[1:]
+			rule.specificity += 100
+		} else {
+			rule.classes = append(rule.classes, part[1:])
+			rule.specificity += 10
+		}
+	}
+
+	return rule, true
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Render renders subject, text and html of template name with data
+//
+// Returns ErrEmailTemplateNotFound if there is no template of name
+func (e *EmailTemplates) Render(name string, data any) (RenderedEmail, error) {
+	tmpl, ok := e.templates[name]
+	if !ok {
+		return RenderedEmail{}, errors.Wrapf(ErrEmailTemplateNotFound, "%q", name)
+	}
+
+	var rendered RenderedEmail
+	var buf bytes.Buffer
+
+	if tmpl.text != nil {
+		if err := tmpl.text.ExecuteTemplate(&buf, tmpl.textName, data); err != nil {
+			return RenderedEmail{}, errors.WithStack(err)
+		}
+
+		rendered.Text = strings.TrimSpace(buf.String())
+		buf.Reset()
+
+		if tmpl.text.Lookup("subject") != nil {
+			if err := tmpl.text.ExecuteTemplate(&buf, "subject", data); err != nil {
+				return RenderedEmail{}, errors.WithStack(err)
+			}
+
+			rendered.Subject = strings.TrimSpace(buf.String())
+			buf.Reset()
+		}
+	}
+
+	if tmpl.html != nil {
+		if err := tmpl.html.ExecuteTemplate(&buf, tmpl.htmlName, data); err != nil {
+			return RenderedEmail{}, errors.WithStack(err)
+		}
+
+		rendered.HTML = strings.TrimSpace(buf.String())
+		buf.Reset()
+
+		if rendered.Subject == "" && tmpl.html.Lookup("subject") != nil {
+			if err := tmpl.html.ExecuteTemplate(&buf, "subject", data); err != nil {
+				return RenderedEmail{}, errors.WithStack(err)
+			}
+
+			// Subject is a header so html escaping is removed
+			rendered.Subject = html.UnescapeString(strings.TrimSpace(buf.String()))
+		}
+
+		if e.config.CSSInliner != nil {
+			var err error
+
+			if rendered.HTML, err = e.config.CSSInliner(rendered.HTML); err != nil {
+				return RenderedEmail{}, err
+			}
+		}
+	}
+
+	return rendered, nil
+}
+
+// Message renders template name with data into copy of msg, where
+// subject of msg is kept if template does not render one
+func (e *EmailTemplates) Message(name string, data any, msg EmailMessage) (EmailMessage, error) {
+	rendered, err := e.Render(name, data)
+	if err != nil {
+		return EmailMessage{}, err
+	}
+
+	if rendered.Subject != "" {
+		msg.Subject = rendered.Subject
+	}
+
+	msg.PlainText = rendered.Text
+	msg.HTML = rendered.HTML
+
+	return msg, nil
+}
+
+// Names returns sorted names of loaded templates
+func (e *EmailTemplates) Names() []string {
+	names := make([]string, 0, len(e.templates))
+
+	for name := range e.templates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func (c cssRule) matches(tag, id string, classes []string) bool {
+	if c.tag != "" && c.tag != tag {
+		return false
+	}
+	if c.id != "" && c.id != id {
+		return false
+	}
+
+	for _, class := range c.classes {
+		found := false
+
+		for _, elemClass := range classes {
+			if class == elemClass {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,134 @@
+package webutil
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func getTestEmailTemplateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"emails/layouts/base.html": {Data: []byte(
+			`<html><head><style>p { color: red } .button { padding: 4px } a.button { color: blue }</style></head>` +
+				`<body>{{template "content" .}}</body></html>`,
+		)},
+		"emails/layouts/base.txt":      {Data: []byte("{{template \"content\" .}}\n-- {{company}}")},
+		"emails/partials/button.html":  {Data: []byte(`<a class="button" href="{{.URL}}">{{.Label}}</a>`)},
+		"emails/welcome.html":          {Data: []byte(`{{define "content"}}<p>Hi {{.Name}}</p>{{template "partials/button.html" .}}{{end}}`)},
+		"emails/welcome.txt":           {Data: []byte(`{{define "subject"}}Welcome {{.Name}} & co{{end}}{{define "content"}}Hi {{.Name}}, visit {{.URL}}{{end}}`)},
+		"emails/reset.html":            {Data: []byte(`{{define "subject"}}Reset for {{.Name}}{{end}}{{define "content"}}<p>Reset</p>{{end}}`)},
+		"emails/notice.txt":            {Data: []byte(`{{define "content"}}Notice{{end}}`)},
+		"emails/README.md":             {Data: []byte("ignored")},
+		"emails/partials/ignored.json": {Data: []byte("{}")},
+	}
+}
+
+func TestEmailTemplatesUnitTest(t *testing.T) {
+	templates, err := NewEmailTemplates(getTestEmailTemplateFS(), EmailTemplatesConfig{
+		Dir:        "emails",
+		Layout:     "base",
+		Funcs:      map[string]any{"company": func() string { return "Acme" }},
+		CSSInliner: InlineCSS,
+	})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if names := strings.Join(templates.Names(), ","); names != "notice,reset,welcome" {
+		t.Errorf("should have notice,reset,welcome; got %s\n", names)
+	}
+
+	data := map[string]string{"Name": "<Bob>", "URL": "https://example.com", "Label": "Go"}
+
+	rendered, err := templates.Render("welcome", data)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if rendered.Subject != "Welcome <Bob> & co" {
+		t.Errorf("should have unescaped subject; got %s\n", rendered.Subject)
+	}
+	if rendered.Text != "Hi <Bob>, visit https://example.com\n-- Acme" {
+		t.Errorf("should have text within layout; got %s\n", rendered.Text)
+	}
+
+	for _, expected := range []string{
+		`<p style="color: red">Hi &lt;Bob&gt;</p>`,
+		`<a class="button" href="https://example.com" style="padding: 4px; color: blue">Go</a>`,
+	} {
+		if !strings.Contains(rendered.HTML, expected) {
+			t.Errorf("should contain %s; got %s\n", expected, rendered.HTML)
+		}
+	}
+	if strings.Contains(rendered.HTML, "<style>") {
+		t.Errorf("should remove inlined style block; got %s\n", rendered.HTML)
+	}
+
+	// ---------------------------------------------------------------
+
+	msg, err := templates.Message("reset", data, EmailMessage{Subject: "Default", To: []EmailAddress{{Email: "bob@example.com"}}})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if msg.Subject != "Reset for <Bob>" || msg.PlainText != "" || !strings.Contains(msg.HTML, "Reset") {
+		t.Errorf("should have html only message; got %+v\n", msg)
+	}
+	if len(msg.To) != 1 {
+		t.Errorf("should keep recipients\n")
+	}
+
+	msg, _ = templates.Message("notice", data, EmailMessage{Subject: "Default"})
+	if msg.Subject != "Default" || msg.PlainText != "Notice\n-- Acme" || msg.HTML != "" {
+		t.Errorf("should have text only message with default subject; got %+v\n", msg)
+	}
+
+	if _, err = templates.Render("missing", data); !errors.Is(err, ErrEmailTemplateNotFound) {
+		t.Errorf("should have ErrEmailTemplateNotFound; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	fsys := getTestEmailTemplateFS()
+	fsys["emails/broken.html"] = &fstest.MapFile{Data: []byte("{{.Name")}
+
+	if _, err = NewEmailTemplates(fsys, EmailTemplatesConfig{Dir: "emails"}); err == nil {
+		t.Errorf("should have parse error\n")
+	}
+
+	// Without layout templates are rendered on their own
+	templates, err = NewEmailTemplates(fstest.MapFS{"plain.txt": {Data: []byte("Hello {{.}}")}}, EmailTemplatesConfig{})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if rendered, _ = templates.Render("plain", "world"); rendered.Text != "Hello world" {
+		t.Errorf("should have Hello world; got %s\n", rendered.Text)
+	}
+}
+
+func TestInlineCSSUnitTest(t *testing.T) {
+	input := `<style>/* comment */ td, .a { margin: 0; } #main { color: red } div td { color: blue }</style>` +
+		`<style>@media (max-width: 600px) { td { width: 100% } }</style>` +
+		`<table id="main" style='font-family: "Arial"'><tr><td class="a b">x</td></tr></table><br/>`
+
+	output, err := InlineCSS(input)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	for _, expected := range []string{
+		`<table id="main" style="color: red; font-family: &#39;Arial&#39;">`,
+		`<td class="a b" style="margin: 0; margin: 0">`,
+		"div td { color: blue }",
+		"@media (max-width: 600px)",
+		"<br/>",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("should contain %s; got %s\n", expected, output)
+		}
+	}
+
+	if output, _ = InlineCSS("<p>no styles</p>"); output != "<p>no styles</p>" {
+		t.Errorf("should not change html; got %s\n", output)
+	}
+}
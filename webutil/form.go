@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -51,12 +52,23 @@ type PathRegex func(r *http.Request) (string, error)
 type FormCurrency struct {
 	decimal.Decimal
 
-	// CurrencyRegex is used for form validation on validating decimal.Decimal
-	// is in the right format
-	// This will be used in FormCurrency#Validate function
+	// Currency is ISO 4217 currency code, e.g. "USD", used to
+	// enforce the decimal places of the currency within Validate
 	//
-	// Default: USDCurrencyRegex
-	//CurrencyRegex *regexp.Regexp `json:"-"`
+	// Default: "" (no enforcement)
+	Currency string `json:"-"`
+
+	// Rounding is used in UnmarshalJSON to round decimal to the
+	// decimal places of Currency instead of Validate rejecting it
+	//
+	// Default: ROUND_NONE
+	Rounding RoundingMode `json:"-"`
+
+	// Locale is used in UnmarshalJSON to parse string input formatted
+	// for locale, e.g. "1.234,56" for "es"
+	//
+	// Default: "" (only plain decimals are accepted)
+	Locale string `json:"-"`
 
 	// Min is the lowest number decimal allowed
 	//
@@ -84,21 +96,54 @@ func NewFormCurrency(d decimal.Decimal) *FormCurrency {
 }
 
 func (f *FormCurrency) UnmarshalJSON(decimalBytes []byte) error {
-	return f.Decimal.UnmarshalJSON(decimalBytes)
+	var str string
+
+	if f.Locale != "" && json.Unmarshal(decimalBytes, &str) == nil {
+		d, err := ParseLocaleDecimal(str, f.Locale)
+		if err != nil {
+			return err
+		}
+
+		f.Decimal = d
+	} else if err := f.Decimal.UnmarshalJSON(decimalBytes); err != nil {
+		return err
+	}
+
+	if f.Currency != "" && f.Rounding != ROUND_NONE {
+		d, err := RoundCurrency(f.Decimal, f.Currency, f.Rounding)
+		if err != nil {
+			return err
+		}
+
+		f.Decimal = d
+	}
+
+	return nil
 }
 
 func (f FormCurrency) MarshalJSON() ([]byte, error) {
 	return f.Decimal.MarshalJSON()
 }
 
+// Money returns decimal along with Currency as Money
+func (f FormCurrency) Money() Money {
+	return Money{Amount: f.Decimal, Currency: strings.ToUpper(f.Currency)}
+}
+
 func (f FormCurrency) Validate() error {
+	if f.Currency != "" {
+		if err := f.Money().Validate(); err != nil {
+			return err
+		}
+	}
+
 	if f.Min != nil && f.Decimal.LessThan(*f.Min) {
 		if f.MinError != nil {
 			return f.MinError
 		}
 
 		val, _ := f.Min.Float64()
-		return fmt.Errorf("Can't be less than %v", val)
+		return NewMessageError(MIN_VALUE_CODE, map[string]any{"min": val})
 	}
 
 	if f.Max != nil && f.Decimal.GreaterThan(*f.Max) {
@@ -107,7 +152,7 @@ func (f FormCurrency) Validate() error {
 		}
 
 		val, _ := f.Max.Float64()
-		return fmt.Errorf("Can't be greater than %v", val)
+		return NewMessageError(MAX_VALUE_CODE, map[string]any{"max": val})
 	}
 
 	return nil
@@ -133,7 +178,7 @@ func (i *Int64) UnmarshalJSON(b []byte) error {
 	var s string
 	if err := json.Unmarshal(b, &s); err == nil {
 		if s == "" {
-			i = nil
+			*i = 0
 			return nil
 		}
 
@@ -158,16 +203,21 @@ func (i Int64) String() string {
 	return strconv.FormatInt(int64(i), INT_BASE)
 }
 
+// Scan implements sql.Scanner interface, a NULL value sets i to 0
 func (i *Int64) Scan(value any) error {
 	switch val := value.(type) {
+	case nil:
+		*i = 0
 	case string:
-		num, err := strconv.ParseInt(val, 10, 64)
+		num, err := strconv.ParseInt(val, INT_BASE, INT_BIT_SIZE)
 
 		if err != nil {
 			return err
 		}
 
 		*i = Int64(num)
+	case []byte:
+		return i.Scan(string(val))
 	case int64:
 		*i = Int64(val)
 	default:
@@ -177,12 +227,13 @@ func (i *Int64) Scan(value any) error {
 	return nil
 }
 
-func (i *Int64) Value() (driver.Value, error) {
-	if i == nil {
-		return nil, nil
-	}
+// Value implements driver.Valuer interface
+func (i Int64) Value() (driver.Value, error) {
+	return int64(i), nil
+}
 
-	return int64(*i), nil
+func (i Int64) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
 }
 
 func (i *Int64) UnmarshalText(text []byte) error {
@@ -286,6 +337,28 @@ func (v *validateRequiredRule) Error(message string) *validateRequiredRule {
 type FormValidationConfig struct {
 	PathRegex  PathRegex
 	SQLBindVar int
+
+	// BatchConcurrency is the max number of queries run at the same
+	// time within FormValidation#BatchValidateStruct
+	//
+	// Queries are only run concurrently if queryable is *sql.DB, otherwise
+	// they are run one at a time as a single connection such as *sql.Tx
+	// or *sql.Conn can't run concurrent queries
+	//
+	// Default: DEFAULT_BATCH_CONCURRENCY
+	BatchConcurrency int
+
+	// Cache is used to store results of database validators that
+	// have called Cache()
+	//
+	// Default: nil (no caching)
+	Cache ValidationCache
+
+	// Catalog is used to translate errors of FormValidation#BatchValidateStruct
+	// and FormValidation#ValidateStruct when context has locale set by WithLocale
+	//
+	// Default: DefaultCatalog
+	Catalog *Catalog
 }
 
 // FormValidation is the main struct that other structs will
@@ -293,6 +366,8 @@ type FormValidationConfig struct {
 type FormValidation struct {
 	queryable qrm.Queryable
 	config    FormValidationConfig
+	ctx       context.Context
+	batch     *validationBatch
 }
 
 // NewFormValidation returns *FormValidation instance
@@ -303,25 +378,57 @@ func NewFormValidation(queryable qrm.Queryable, config FormValidationConfig) *Fo
 	}
 }
 
+// WithContext returns shallow copy of *FormValidation bound to ctx
+//
+// Database validators created from the returned *FormValidation pass
+// ctx to the queryable so request cancellation and deadlines stop
+// in-flight validation queries, e.g.
+//
+//	formValidation.WithContext(r.Context()).ValidateExists(...)
+func (f *FormValidation) WithContext(ctx context.Context) *FormValidation {
+	if ctx == nil {
+		panic("webutil: nil context")
+	}
+
+	f2 := new(FormValidation)
+	*f2 = *f
+	f2.ctx = ctx
+	return f2
+}
+
+// Context returns context bound to *FormValidation
+// If no context is bound, context.Background() is returned
+func (f *FormValidation) Context() context.Context {
+	if f.ctx != nil {
+		return f.ctx
+	}
+
+	return context.Background()
+}
+
 // IsValid returns *validRule based on isValid parameter
 // Basically IsValid is a wrapper for the passed bool
 // to return valid rule to then apply custom error message
 // for the Error function
 func (f *FormValidation) IsValid(isValid bool) *validRule {
-	return &validRule{isValid: isValid, err: errors.New("Not Valid")}
+	return &validRule{isValid: isValid, err: NewMessageError(INVALID_CODE, nil)}
 }
 
-// ValidateDate verifies whether a date string matches the passed in
-// layout format
+// ValidateDate verifies whether a date is allowed to be a past or
+// future date of the current time and within bounds set by Min and Max
 //
-// If a user wishes, they can also verify whether the given date is
-// allowed to be a past or future date of the current time
+// The date can be time.Time, FormDate, FormDateTime or a string in
+// FORM_DATE_TIME_LAYOUT, FORM_DATE_LAYOUT, DATE_TIME_LAYOUT, DATE_LAYOUT
+// or RFC 3339, where strings without an offset are parsed in timezone
 //
-// The timezone parameter converts given time to compare to current
-// time if you choose to
+// The timezone parameter is the timezone dates are converted to before
+// being compared to the current time in the same timezone, where
+// time.Time keeps its wall clock, e.g. 00:00 UTC becomes 00:00 in
+// timezone, and FormDate and FormDateTime are converted to timezone
 // If no timezone is passed, UTC is used by default
-// If user does not want to compare time, both bool parameters
-// should be true
+// If compareTime is false, only the dates are compared
+// If user does not want to restrict past or future dates, both bool
+// parameters should be true
 //
 // Will raise errFutureAndPastDateInternal error which will be wrapped
 // in validation.InternalError if both bool parameters are false
@@ -358,11 +465,14 @@ func (f *FormValidation) ValidateArgs(
 	return &validateArgsRule{
 		validator: &validator{
 			queryable:      f.queryable,
+			ctx:            f.Context(),
+			batch:          f.batch,
+			cache:          f.config.Cache,
 			placeHolderIdx: placeHolderIdx,
 			bindVar:        f.config.SQLBindVar,
 			query:          query,
 			args:           args,
-			err:            errors.New(INVALID_TXT),
+			err:            NewMessageError(INVALID_CODE, nil),
 		},
 	}
 }
@@ -379,11 +489,14 @@ func (f *FormValidation) ValidateUniqueness(
 		instanceValue: instanceValue,
 		validator: &validator{
 			queryable:      f.queryable,
+			ctx:            f.Context(),
+			batch:          f.batch,
+			cache:          f.config.Cache,
 			placeHolderIdx: placeHolderIdx,
 			bindVar:        f.config.SQLBindVar,
 			query:          query,
 			args:           args,
-			err:            errors.New(ALREADY_EXISTS_TXT),
+			err:            NewMessageError(ALREADY_EXISTS_CODE, nil),
 		},
 	}
 }
@@ -399,11 +512,14 @@ func (f *FormValidation) ValidateExists(
 	return &validateExistsRule{
 		validator: &validator{
 			queryable:      f.queryable,
+			ctx:            f.Context(),
+			batch:          f.batch,
+			cache:          f.config.Cache,
 			placeHolderIdx: placeHolderIdx,
 			bindVar:        f.config.SQLBindVar,
 			query:          query,
 			args:           args,
-			err:            errors.New(DOES_NOT_EXIST_TXT),
+			err:            NewMessageError(DOES_NOT_EXIST_CODE, nil),
 		},
 	}
 }
@@ -430,6 +546,11 @@ func (f *FormValidation) SetConfig(config FormValidationConfig) {
 
 type validator struct {
 	queryable      qrm.Queryable
+	ctx            context.Context
+	batch          *validationBatch
+	cache          ValidationCache
+	cacheTags      []string
+	useCache       bool
 	args           []any
 	query          string
 	bindVar        int
@@ -442,54 +563,86 @@ type validateDateRule struct {
 	compareTime bool
 	canBeFuture bool
 	canBePast   bool
+	min         *time.Time
+	max         *time.Time
 	err         error
 }
 
 func (v *validateDateRule) Validate(value any) error {
-	var currentTime, dateTime time.Time
+	var dateTime time.Time
 	var err error
 
 	if isNilValue(value) {
 		return nil
 	}
 
-	tz := v.timezone
-	if tz == "" {
-		tz = "UTC"
-	}
-
-	if currentTime, err = ConvertToTimezone(time.Now(), tz, v.compareTime); err != nil {
+	loc, err := formLocation(v.timezone)
+	if err != nil {
 		return err
 	}
 
 	switch val := value.(type) {
-	case time.Time:
-		dateTime = val
-	case *time.Time:
-		dateTime = *val
+	case string:
+		if dateTime, err = parseInLocation(val, loc, formDateTimeLayouts); err != nil {
+			return v.error(INVALID_FORMAT_CODE, nil)
+		}
+	case *string:
+		if dateTime, err = parseInLocation(*val, loc, formDateTimeLayouts); err != nil {
+			return v.error(INVALID_FORMAT_CODE, nil)
+		}
+	case time.Time, *time.Time:
+		// Raw times keep their wall clock within timezone where
+		// FormDate and FormDateTime are already within a timezone
+		if dateTime, err = ruleTime(value); err != nil {
+			return err
+		}
+		if dateTime, err = ResolveWallClock(dateTime, loc, DSTPolicy{}); err != nil {
+			return err
+		}
 	default:
-		return errors.New("Must be time.Time or *time.Time type")
+		if dateTime, err = ruleTime(value); err != nil {
+			return v.error(INVALID_FORMAT_CODE, nil)
+		}
 	}
 
-	if dateTime, err = ConvertToTimezone(dateTime, tz, v.compareTime); err != nil {
-		return err
+	if !v.canBeFuture && !v.canBePast {
+		return validation.NewInternalError(errFutureAndPastDateInternal)
 	}
 
-	if v.canBeFuture && v.canBePast {
-		err = nil
-	} else if v.canBeFuture {
-		if dateTime.Before(currentTime) {
-			err = errors.New(INVALID_PAST_DATE_TXT)
+	currentTime := v.truncate(time.Now(), loc)
+	dateTime = v.truncate(dateTime, loc)
+
+	if !v.canBePast && dateTime.Before(currentTime) {
+		return v.error(INVALID_PAST_DATE_CODE, nil)
+	}
+	if !v.canBeFuture && dateTime.After(currentTime) {
+		return v.error(INVALID_FUTURE_DATE_CODE, nil)
+	}
+
+	if v.min != nil {
+		if min := v.truncate(*v.min, loc); dateTime.Before(min) {
+			return v.error(DATE_BEFORE_MIN_CODE, map[string]any{"min": v.format(min)})
 		}
-	} else if v.canBePast {
-		if dateTime.After(currentTime) {
-			err = errors.New(INVALID_FUTURE_DATE_TXT)
+	}
+	if v.max != nil {
+		if max := v.truncate(*v.max, loc); dateTime.After(max) {
+			return v.error(DATE_AFTER_MAX_CODE, map[string]any{"max": v.format(max)})
 		}
-	} else {
-		err = validation.NewInternalError(errFutureAndPastDateInternal)
 	}
 
-	return err
+	return nil
+}
+
+// Min sets earliest date allowed
+func (v *validateDateRule) Min(min time.Time) *validateDateRule {
+	v.min = &min
+	return v
+}
+
+// Max sets latest date allowed
+func (v *validateDateRule) Max(max time.Time) *validateDateRule {
+	v.max = &max
+	return v
 }
 
 func (v *validateDateRule) Error(message string) *validateDateRule {
@@ -497,6 +650,35 @@ func (v *validateDateRule) Error(message string) *validateDateRule {
 	return v
 }
 
+// truncate converts t to loc and removes time if
+// time is not being compared
+func (v *validateDateRule) truncate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+
+	if v.compareTime {
+		return t
+	}
+
+	return startOfDay(t.Year(), t.Month(), t.Day(), loc)
+}
+
+func (v *validateDateRule) format(t time.Time) string {
+	if v.compareTime {
+		return t.Format(FORM_DATE_TIME_LAYOUT)
+	}
+
+	return t.Format(FORM_DATE_LAYOUT)
+}
+
+// error returns custom error if set else *MessageError of code
+func (v *validateDateRule) error(code string, params map[string]any) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	return NewMessageError(code, params)
+}
+
 type validRule struct {
 	isValid bool
 	err     error
@@ -529,6 +711,14 @@ func (v *validateExistsRule) Error(message string) *validateExistsRule {
 	return v
 }
 
+// Cache stores result of rule in ValidationCache of FormValidationConfig
+// with tags that can be used to invalidate result
+func (v *validateExistsRule) Cache(tags ...string) *validateExistsRule {
+	v.useCache = true
+	v.cacheTags = tags
+	return v
+}
+
 type validateUniquenessRule struct {
 	*validator
 	instanceValue any
@@ -557,6 +747,14 @@ func (v *validateUniquenessRule) Error(message string) *validateUniquenessRule {
 	return v
 }
 
+// Cache stores result of rule in ValidationCache of FormValidationConfig
+// with tags that can be used to invalidate result
+func (v *validateUniquenessRule) Cache(tags ...string) *validateUniquenessRule {
+	v.useCache = true
+	v.cacheTags = tags
+	return v
+}
+
 type validateArgsRule struct {
 	*validator
 }
@@ -570,15 +768,101 @@ func (v *validateArgsRule) Error(message string) *validateArgsRule {
 	return v
 }
 
+// Cache stores result of rule in ValidationCache of FormValidationConfig
+// with tags that can be used to invalidate result
+func (v *validateArgsRule) Cache(tags ...string) *validateArgsRule {
+	v.useCache = true
+	v.cacheTags = tags
+	return v
+}
+
 func validatorRules(v *validator, value any, validateType int) error {
 	if isNilValue(value) {
 		return nil
 	}
 
-	var err error
+	query, args, expectedLen, err := validatorQuery(v, value)
+	if err != nil {
+		return errors.WithStack(validation.NewInternalError(err))
+	}
+
+	// If type is slice and is empty, simply return nil as we will get an error
+	// when trying to query with empty slice
+	if expectedLen == 0 {
+		return nil
+	}
+
+	if v.batch != nil {
+		return v.batch.validate(v, validateType, query, args, expectedLen)
+	}
+
+	if counter, ok := v.cacheGet(validateType, query, args); ok {
+		return validatorResult(v, validateType, counter, expectedLen)
+	}
+
+	counter, err := validatorRowCount(v, query, args)
+	if err != nil {
+		return err
+	}
+
+	v.cacheSet(validateType, query, args, counter)
+	return validatorResult(v, validateType, counter, expectedLen)
+}
+
+// cacheGet returns number of rows stored for query if
+// validator uses cache
+func (v *validator) cacheGet(validateType int, query string, args []any) (int, bool) {
+	if !v.useCache || v.cache == nil {
+		return 0, false
+	}
+
+	return v.cache.Get(validationCacheKey(validateType, query, args))
+}
+
+// cacheSet stores number of rows found for query if
+// validator uses cache
+func (v *validator) cacheSet(validateType int, query string, args []any, counter int) {
+	if !v.useCache || v.cache == nil {
+		return
+	}
+
+	v.cache.Set(validationCacheKey(validateType, query, args), counter, v.cacheTags)
+}
+
+// validatorRowCount runs query of validator and returns number of rows found
+func validatorRowCount(v *validator, query string, args []any) (int, error) {
+	ctx := v.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	rows, err := v.queryable.QueryContext(ctx, query, args...)
+	if err != nil {
+		msg := fmt.Errorf("err: %w\n query:%s\n args:%v\n", err, v.query, args)
+		return 0, errors.WithStack(validation.NewInternalError(msg))
+	}
+	defer rows.Close()
+
+	counter := 0
+
+	for rows.Next() {
+		counter++
+	}
+
+	if err = rows.Err(); err != nil {
+		msg := fmt.Errorf("err: %w\n query:%s\n args:%v\n", err, v.query, args)
+		return 0, errors.WithStack(validation.NewInternalError(msg))
+	}
+
+	return counter, nil
+}
+
+// validatorQuery inserts value into args of validator at its placeholder
+// index and returns rebound query along with the number of values
+// that were searched for
+func validatorQuery(v *validator, value any) (string, []any, int, error) {
 	var expectedLen int
 	var tmpVal any
-	var args []any
 
 	switch reflect.TypeOf(value).Kind() {
 	case reflect.Slice:
@@ -592,10 +876,8 @@ func validatorRules(v *validator, value any, validateType int) error {
 			searchVals = append(searchVals, i)
 		}
 
-		// If type is slice and is empty, simply return nil as we will get an error
-		// when trying to query with empty slice
 		if len(searchVals) == 0 {
-			return nil
+			return "", nil, 0, nil
 		}
 
 		tmpVal = searchVals
@@ -604,7 +886,7 @@ func validatorRules(v *validator, value any, validateType int) error {
 		expectedLen = 1
 	}
 
-	args = make([]any, 0, len(v.args)+1)
+	args := make([]any, 0, len(v.args)+1)
 	args = append(args, v.args...)
 
 	if v.placeHolderIdx > -1 {
@@ -613,21 +895,15 @@ func validatorRules(v *validator, value any, validateType int) error {
 
 	query, args, err := InQueryRebind(v.bindVar, v.query, args...)
 	if err != nil {
-		return errors.WithStack(validation.NewInternalError(err))
-	}
-
-	rows, err := v.queryable.QueryContext(context.Background(), query, args...)
-	if err != nil {
-		msg := fmt.Errorf("err: %s\n query:%s\n args:%v\n", err, v.query, args)
-		return errors.WithStack(validation.NewInternalError(msg))
+		return "", nil, 0, err
 	}
 
-	counter := 0
-
-	for rows.Next() {
-		counter++
-	}
+	return query, args, expectedLen, nil
+}
 
+// validatorResult returns error of validator if number of rows
+// found does not satisfy validateType
+func validatorResult(v *validator, validateType, counter, expectedLen int) error {
 	switch validateType {
 	case validateArgsType:
 		if counter != expectedLen {
@@ -679,7 +955,12 @@ func CheckBodyAndDecode(req *http.Request, form any, excludeMethods ...string) e
 		dec := json.NewDecoder(req.Body)
 
 		if err := dec.Decode(&form); err != nil {
-			return fmt.Errorf("%w: %s", err, ErrInvalidJSON)
+			// Body with no content has nothing useful to add
+			if errors.Is(err, io.EOF) {
+				return ErrInvalidJSON
+			}
+
+			return fmt.Errorf("%w: %w", err, ErrInvalidJSON)
 		}
 	} else {
 		if !canSkip {
@@ -2,12 +2,16 @@ package webutil
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
 	"time"
 
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	validation "github.com/go-ozzo/ozzo-validation"
 	pkgerrors "github.com/pkg/errors"
 )
 
@@ -97,6 +101,12 @@ func TestValidateDateRuleUnitTest(t *testing.T) {
 		canBePast:   true,
 	}
 
+	var msgErr *MessageError
+
+	if err = rule.Validate("not a date"); !errors.As(err, &msgErr) || msgErr.Code != INVALID_FORMAT_CODE {
+		t.Errorf("should have %s *MessageError; got %v\n", INVALID_FORMAT_CODE, err)
+	}
+
 	if err = rule.Validate(pastDateStr); err != nil {
 		t.Errorf("should not have error\n")
 		t.Errorf("err: %s\n", err.Error())
@@ -178,6 +188,29 @@ func TestValidateDateRuleUnitTest(t *testing.T) {
 	} else if err.Error() != INVALID_FUTURE_DATE_TXT {
 		t.Errorf("should have future date error; got %s\n", err.Error())
 	}
+
+	// -------------------------------------------------------------------------
+
+	// Raw times use their wall clock within timezone so midnight UTC
+	// is still same day in "America/New_York"
+	newYork, _ := time.LoadLocation("America/New_York")
+	midnightUTC := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	rule = (&validateDateRule{
+		timezone:    "America/New_York",
+		canBeFuture: true,
+		canBePast:   true,
+	}).Min(time.Date(2030, 1, 10, 0, 0, 0, 0, newYork))
+
+	if err = rule.Validate(midnightUTC); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if err = rule.Validate(&midnightUTC); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if err = rule.Validate(FormDateTime{Time: midnightUTC}); !errors.As(err, &msgErr) || msgErr.Code != DATE_BEFORE_MIN_CODE {
+		t.Errorf("should have %s *MessageError; got %v\n", DATE_BEFORE_MIN_CODE, err)
+	}
 }
 
 func TestCheckBodyAndDecodeUnitTest(t *testing.T) {
@@ -297,3 +330,49 @@ func TestCheckBodyAndDecodeUnitTest(t *testing.T) {
 // 		}
 // 	}
 // }
+
+func TestFormValidationWithContextUnitTest(t *testing.T) {
+	var err error
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	fv := NewFormValidation(db, FormValidationConfig{SQLBindVar: DOLLAR_SQL_BIND_VAR})
+
+	if fv.Context() != context.Background() {
+		t.Errorf("default context should be context.Background()\n")
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM user WHERE id = $1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if err = fv.ValidateExists(0, "SELECT id FROM user WHERE id = ?").Validate(1); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM user WHERE id = $1")).
+		WithArgs(1).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	ctxFV := fv.WithContext(ctx)
+
+	if ctxFV == fv || fv.Context() != context.Background() {
+		t.Errorf("WithContext should return copy\n")
+	}
+
+	if err = ctxFV.ValidateExists(0, "SELECT id FROM user WHERE id = ?").Validate(1); err == nil {
+		t.Errorf("should have error\n")
+	} else if internalErr, ok := pkgerrors.Cause(err).(validation.InternalError); !ok {
+		t.Errorf("should be validation.InternalError; got %T\n", pkgerrors.Cause(err))
+	} else if !errors.Is(internalErr.InternalError(), context.Canceled) {
+		t.Errorf("should have context.Canceled error; got %s\n", err.Error())
+	}
+}
@@ -0,0 +1,502 @@
+package webutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Migration represents a single versioned schema change loaded from
+// a pair of "<version>_<name>.up.sql" and "<version>_<name>.down.sql" files
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is returned from Migrator#Status to show whether
+// each migration has been applied to the database
+type MigrationStatus struct {
+	Version uint64
+	Name    string
+	Applied bool
+
+	// AppliedAt is the raw value stored in the migration table
+	// as each driver returns timestamps in a different format
+	AppliedAt string
+}
+
+// MigratorConfig is config struct used in the initialization
+// of *Migrator
+type MigratorConfig struct {
+	// DBType should be one of POSTGRES_DRIVER, MYSQL_DRIVER or SQLITE_DRIVER
+	// and determines the bind vars and advisory lock used
+	DBType string
+
+	// Dir is the directory within the fs.FS that contains migration files
+	//
+	// Default: "."
+	Dir string
+
+	// TableName is the table that applied versions are recorded in
+	//
+	// Default: DEFAULT_MIGRATION_TABLE
+	TableName string
+
+	// LockID is the key used for advisory lock so concurrent
+	// deploys don't run migrations at the same time
+	//
+	// Default: DEFAULT_MIGRATION_LOCK_ID
+	LockID int64
+}
+
+// migrationQueryer is implemented by both Database and *sql.Conn so
+// migration table queries can run on either
+type migrationQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// migrationConnector is implemented by *sql.DB to pin a single connection
+type migrationConnector interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// Migrator reads migrations from fs.FS and applies them to database
+//
+// Each migration runs within a transaction along with recording its
+// version, but mysql implicitly commits DDL statements such as CREATE
+// and ALTER so mysql migrations are not atomic.  A mysql migration that
+// fails may be partially applied without its version being recorded,
+// so mysql migrations should have a single DDL statement each or be
+// written so they can be safely run again, ex. "IF NOT EXISTS"
+type Migrator struct {
+	db         Database
+	config     MigratorConfig
+	migrations []Migration
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewMigrator returns *Migrator instance with migrations loaded from fsys
+//
+// Migrations run on a single connection taken from db, which must
+// implement Conn(ctx) like *sql.DB, so the advisory lock is held on the
+// same session that applies migrations and a pool of one connection works
+//
+// Will return error if DBType is not supported, a migration file is
+// missing its up or down pair or if two migrations share a version
+func NewMigrator(db Database, fsys fs.FS, config MigratorConfig) (*Migrator, error) {
+	switch config.DBType {
+	case POSTGRES_DRIVER, MYSQL_DRIVER, SQLITE_DRIVER:
+	default:
+		return nil, errors.WithStack(fmt.Errorf("webutil: unsupported migration db type %q", config.DBType))
+	}
+
+	if _, ok := db.(migrationConnector); !ok {
+		return nil, errors.WithStack(fmt.Errorf("webutil: migration db %T must implement Conn(ctx)", db))
+	}
+
+	if config.Dir == "" {
+		config.Dir = "."
+	}
+	if config.TableName == "" {
+		config.TableName = DEFAULT_MIGRATION_TABLE
+	}
+	if config.LockID == 0 {
+		config.LockID = DEFAULT_MIGRATION_LOCK_ID
+	}
+
+	migrations, err := LoadMigrations(fsys, config.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		config:     config,
+		migrations: migrations,
+	}, nil
+}
+
+// LoadMigrations reads all migration files within dir of fsys and
+// returns them sorted by version
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	migrationMap := make(map[uint64]*Migration)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := MigrationFileRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], INT_BASE, INT_BIT_SIZE)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		m, ok := migrationMap[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			migrationMap[version] = m
+		} else if m.Name != matches[2] {
+			return nil, errors.WithStack(
+				fmt.Errorf("webutil: duplicate migration version %d (%q and %q)", version, m.Name, matches[2]),
+			)
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationMap))
+
+	for _, m := range migrationMap {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.WithStack(
+				fmt.Errorf("webutil: migration %d_%s must have both up and down files", m.Version, m.Name),
+			)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Migrations returns all migrations loaded sorted by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up applies every migration that has not yet been applied
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sql.Conn, applied map[uint64]string) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down rolls back the last number of applied migrations based on steps
+// If steps is less than 1, only the latest applied migration is rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		steps = 1
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn, applied map[uint64]string) error {
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; !ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, m.migrations[i], false); err != nil {
+				return err
+			}
+
+			steps--
+		}
+
+		return nil
+	})
+}
+
+// Goto migrates database up or down until version is the latest
+// applied migration
+//
+// Passing version 0 will roll back every migration
+func (m *Migrator) Goto(ctx context.Context, version uint64) error {
+	if version != 0 {
+		found := false
+
+		for _, migration := range m.migrations {
+			if migration.Version == version {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return errors.WithStack(ErrMigrationVersionNotFound)
+		}
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn, applied map[uint64]string) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if m.migrations[i].Version <= version {
+				break
+			}
+			if _, ok := applied[m.migrations[i].Version]; !ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, m.migrations[i], false); err != nil {
+				return err
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Status returns every loaded migration along with whether it
+// has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.createTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+
+	applied, err := m.appliedVersions(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// withLock takes advisory lock for the dialect on a dedicated connection,
+// ensures the migration table exists and passes the connection along with
+// applied versions to fn so every statement runs on the locked session
+//
+// Sqlite has no advisory locks but only allows a single writer so
+// the primary key on the migration table guards against double applies
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, applied map[uint64]string) error) (err error) {
+	conn, err := m.db.(migrationConnector).Conn(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	if m.config.DBType != SQLITE_DRIVER {
+		if err = m.lock(ctx, conn); err != nil {
+			return err
+		}
+
+		defer func() {
+			// Lock is released even if ctx was cancelled as the
+			// connection goes back to the pool still holding it
+			if unlockErr := m.unlock(context.WithoutCancel(ctx), conn); unlockErr != nil {
+				conn.Raw(func(any) error { return driver.ErrBadConn })
+
+				if err == nil {
+					err = unlockErr
+				}
+			}
+		}()
+	}
+
+	if err = m.createTable(ctx, conn); err != nil {
+		return err
+	}
+
+	applied, err := m.appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn) error {
+	switch m.config.DBType {
+	case POSTGRES_DRIVER:
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.config.LockID); err != nil {
+			return errors.WithStack(err)
+		}
+	case MYSQL_DRIVER:
+		var res sql.NullInt64
+
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", m.mysqlLockName()).Scan(&res); err != nil {
+			return errors.WithStack(err)
+		}
+		if res.Int64 != 1 {
+			return errors.WithStack(ErrMigrationLock)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) unlock(ctx context.Context, conn *sql.Conn) error {
+	var err error
+
+	switch m.config.DBType {
+	case POSTGRES_DRIVER:
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", m.config.LockID)
+	case MYSQL_DRIVER:
+		_, err = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", m.mysqlLockName())
+	}
+
+	return errors.WithStack(err)
+}
+
+func (m *Migrator) mysqlLockName() string {
+	return fmt.Sprintf("webutil_migrate_%d", m.config.LockID)
+}
+
+func (m *Migrator) bindVar() int {
+	if m.config.DBType == POSTGRES_DRIVER {
+		return DOLLAR_SQL_BIND_VAR
+	}
+
+	return QUESTION_SQL_BIND_VAR
+}
+
+func (m *Migrator) createTable(ctx context.Context, db migrationQueryer) error {
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)",
+		m.config.TableName,
+	)
+
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, db migrationQueryer) (map[uint64]string, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		fmt.Sprintf("SELECT version, applied_at FROM %s", m.config.TableName),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]string)
+
+	for rows.Next() {
+		var version int64
+		var appliedAt sql.NullString
+
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		applied[uint64(version)] = appliedAt.String
+	}
+
+	return applied, errors.WithStack(rows.Err())
+}
+
+// apply runs either the up or down sql of migration and records
+// the result in the migration table within the same transaction
+//
+// Mysql commits DDL statements as they run so see Migrator
+// on why failed mysql migrations may be partially applied
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var migrationSQL, recordSQL string
+	var args []any
+
+	if up {
+		migrationSQL = migration.Up
+		recordSQL = fmt.Sprintf("INSERT INTO %s (version, name) VALUES (?, ?)", m.config.TableName)
+		args = []any{int64(migration.Version), migration.Name}
+	} else {
+		migrationSQL = migration.Down
+		recordSQL = fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.config.TableName)
+		args = []any{int64(migration.Version)}
+	}
+
+	if _, err = tx.ExecContext(ctx, migrationSQL); err != nil {
+		tx.Rollback()
+
+		if m.config.DBType == MYSQL_DRIVER {
+			return errors.Wrapf(
+				err,
+				"webutil: migration %d_%s failed and may be partially applied as mysql commits DDL statements",
+				migration.Version,
+				migration.Name,
+			)
+		}
+
+		return errors.Wrapf(err, "webutil: migration %d_%s failed", migration.Version, migration.Name)
+	}
+
+	if _, err = tx.ExecContext(ctx, Rebind(m.bindVar(), recordSQL), args...); err != nil {
+		tx.Rollback()
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(tx.Commit())
+}
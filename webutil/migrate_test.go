@@ -0,0 +1,228 @@
+package webutil
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func getTestMigrationFS() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0001_create_user.up.sql":    {Data: []byte("CREATE TABLE user (id INT)")},
+		"migrations/0001_create_user.down.sql":  {Data: []byte("DROP TABLE user")},
+		"migrations/0002_create_phone.up.sql":   {Data: []byte("CREATE TABLE phone (id INT)")},
+		"migrations/0002_create_phone.down.sql": {Data: []byte("DROP TABLE phone")},
+		"migrations/README.md":                  {Data: []byte("ignored")},
+	}
+}
+
+func TestLoadMigrationsUnitTest(t *testing.T) {
+	migrations, err := LoadMigrations(getTestMigrationFS(), "migrations")
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("should have 2 migrations; got %d\n", len(migrations))
+	}
+
+	if migrations[0].Version != 1 || migrations[0].Name != "create_user" {
+		t.Errorf("first migration should be 1_create_user; got %d_%s\n", migrations[0].Version, migrations[0].Name)
+	}
+
+	fsys := getTestMigrationFS()
+	delete(fsys, "migrations/0002_create_phone.down.sql")
+
+	if _, err = LoadMigrations(fsys, "migrations"); err == nil {
+		t.Errorf("should have error for missing down file\n")
+	}
+
+	if _, err = NewMigrator(nil, getTestMigrationFS(), MigratorConfig{DBType: "invalid"}); err == nil {
+		t.Errorf("should have error for invalid db type\n")
+	}
+}
+
+func TestMigratorUnitTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	// Every statement should run on the locked connection
+	db.SetMaxOpenConns(1)
+
+	type noConnDB struct {
+		Database
+	}
+
+	if _, err = NewMigrator(noConnDB{db}, getTestMigrationFS(), MigratorConfig{DBType: POSTGRES_DRIVER}); err == nil {
+		t.Errorf("should have error for db without Conn\n")
+	}
+
+	m, err := NewMigrator(db, getTestMigrationFS(), MigratorConfig{
+		DBType: POSTGRES_DRIVER,
+		Dir:    "migrations",
+	})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	createQuery := regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS " + DEFAULT_MIGRATION_TABLE)
+	selectQuery := regexp.QuoteMeta("SELECT version, applied_at FROM " + DEFAULT_MIGRATION_TABLE)
+
+	// ---------------------------------------------------------------------
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(DEFAULT_MIGRATION_LOCK_ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, "2024-01-01"),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE phone (id INT)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO "+DEFAULT_MIGRATION_TABLE+" (version, name) VALUES ($1, $2)")).
+		WithArgs(2, "create_phone").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(DEFAULT_MIGRATION_LOCK_ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = m.Up(context.Background()); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(createQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"version", "applied_at"}).
+			AddRow(1, "2024-01-01").
+			AddRow(2, "2024-01-02"),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DROP TABLE phone")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM " + DEFAULT_MIGRATION_TABLE + " WHERE version = $1")).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = m.Goto(context.Background(), 1); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	if err = m.Goto(context.Background(), 3); !errors.Is(err, ErrMigrationVersionNotFound) {
+		t.Errorf("should have ErrMigrationVersionNotFound error; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------------
+
+	mock.ExpectExec(createQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectQuery).WillReturnRows(
+		sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, "2024-01-01"),
+	)
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if !statuses[0].Applied || statuses[0].AppliedAt != "2024-01-01" {
+		t.Errorf("migration 1 should be applied\n")
+	}
+	if statuses[1].Applied {
+		t.Errorf("migration 2 should not be applied\n")
+	}
+}
+
+func TestMigratorSqliteDownUnitTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	m, err := NewMigrator(db, getTestMigrationFS(), MigratorConfig{
+		DBType: SQLITE_DRIVER,
+		Dir:    "migrations",
+	})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, applied_at").WillReturnRows(
+		sqlmock.NewRows([]string{"version", "applied_at"}).
+			AddRow(1, "2024-01-01").
+			AddRow(2, "2024-01-02"),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE phone").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("WHERE version = ?")).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE user").WillReturnError(errors.New("drop error"))
+	mock.ExpectRollback()
+
+	if err = m.Down(context.Background(), 2); err == nil {
+		t.Errorf("should have error\n")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
+
+func TestMigratorMysqlFailureUnitTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	m, err := NewMigrator(db, getTestMigrationFS(), MigratorConfig{
+		DBType: MYSQL_DRIVER,
+		Dir:    "migrations",
+	})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK(?, -1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, applied_at").WillReturnRows(
+		sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, "2024-01-01"),
+	)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE phone (id INT)")).WillReturnError(errors.New("create error"))
+	mock.ExpectRollback()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK(?)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err = m.Up(context.Background()); err == nil || !strings.Contains(err.Error(), "partially applied") {
+		t.Errorf("should have partially applied error; got %v\n", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,359 @@
+package webutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// MultipartOptions is config struct used in ParseMultipartRequest
+type MultipartOptions struct {
+	// MaxMemory is the number of bytes of file parts kept in memory,
+	// anything above is spooled to temp files on disk
+	//
+	// Default: DEFAULT_MULTIPART_MAX_MEMORY
+	MaxMemory int64
+
+	// MaxBodyBytes is max size of request body allowed
+	//
+	// Default: 0 (no limit)
+	MaxBodyBytes int64
+
+	// DecodeOptions are used when decoding json form parts,
+	// only DisallowUnknownFields, SingleValue and UseNumber apply
+	DecodeOptions DecodeOptions
+}
+
+// MultipartRequest is parsed multipart request that contains json
+// form parts along with uploaded files, the layout built by
+// webutiltest.MultipartFormRequestBuilder
+type MultipartRequest struct {
+	form *multipart.Form
+	opts MultipartOptions
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// ParseMultipartRequest parses multipart body of req
+//
+// RemoveAll should be deferred after a successful parse so
+// temp files spooled to disk are deleted
+func ParseMultipartRequest(w http.ResponseWriter, req *http.Request, opts MultipartOptions) (*MultipartRequest, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, ErrBodyRequired
+	}
+
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != MULTIPART_FORM_CONTENT_HEADER || params["boundary"] == "" {
+		return nil, ErrUnsupportedContentType
+	}
+
+	if opts.MaxMemory <= 0 {
+		opts.MaxMemory = DEFAULT_MULTIPART_MAX_MEMORY
+	}
+
+	body := req.Body
+
+	if opts.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(w, req.Body, opts.MaxBodyBytes)
+	}
+
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(opts.MaxMemory)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+
+		if errors.As(err, &maxBytesErr) {
+			return nil, ErrBodyTooLarge
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMultipart, err)
+	}
+
+	return &MultipartRequest{form: form, opts: opts}, nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// DecodeForm json decodes the form part of key into form
+//
+// Returns ErrBodyRequired if there is no part for key and
+// *DecodeError if part is invalid json
+func (m *MultipartRequest) DecodeForm(key string, form any) error {
+	vals := m.form.Value[key]
+
+	if len(vals) == 0 {
+		return ErrBodyRequired
+	}
+
+	dec := json.NewDecoder(strings.NewReader(vals[0]))
+
+	if m.opts.DecodeOptions.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if m.opts.DecodeOptions.UseNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(form); err != nil {
+		return decodeError(dec, err)
+	}
+
+	if m.opts.DecodeOptions.SingleValue {
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return &DecodeError{
+				Field:  key,
+				Offset: dec.InputOffset(),
+				Msg:    "form must only contain a single json value",
+				Err:    err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// Value returns the raw value of the form part of key
+func (m *MultipartRequest) Value(key string) string {
+	if vals := m.form.Value[key]; len(vals) > 0 {
+		return vals[0]
+	}
+
+	return ""
+}
+
+// Files returns uploaded files of key
+func (m *MultipartRequest) Files(key string) []*multipart.FileHeader {
+	return m.form.File[key]
+}
+
+// Form returns the underlying *multipart.Form
+func (m *MultipartRequest) Form() *multipart.Form {
+	return m.form
+}
+
+// RemoveAll removes temp files that were spooled to disk
+func (m *MultipartRequest) RemoveAll() error {
+	return m.form.RemoveAll()
+}
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- RULES -----------------------------
+//////////////////////////////////////////////////////////////////
+
+// ValidateFileCount verifies number of uploaded files is between min and max
+// If max is 0, there is no upper limit
+func (f *FormValidation) ValidateFileCount(min, max int) *validateFileCountRule {
+	return &validateFileCountRule{
+		min: min,
+		max: max,
+		err: NewMessageError(INVALID_FILE_COUNT_CODE, map[string]any{"min": min, "max": max}),
+	}
+}
+
+// ValidateFileSize verifies every uploaded file is no more than maxBytes
+func (f *FormValidation) ValidateFileSize(maxBytes int64) *validateFileSizeRule {
+	return &validateFileSizeRule{
+		maxBytes: maxBytes,
+		err:      NewMessageError(FILE_TOO_LARGE_CODE, map[string]any{"max": maxBytes}),
+	}
+}
+
+// ValidateFileType verifies the content type of every uploaded file is
+// one of mimeTypes
+//
+// The content type is sniffed from the file contents with
+// http.DetectContentType, the header sent by the client is not trusted
+func (f *FormValidation) ValidateFileType(mimeTypes ...string) *validateFileTypeRule {
+	return &validateFileTypeRule{
+		mimeTypes: mimeTypes,
+		err:       NewMessageError(INVALID_FILE_TYPE_CODE, nil),
+	}
+}
+
+// ValidateFileExtension verifies the extension of every uploaded file
+// name is one of exts, e.g. ".png"
+func (f *FormValidation) ValidateFileExtension(exts ...string) *validateFileExtensionRule {
+	return &validateFileExtensionRule{
+		exts: exts,
+		err:  NewMessageError(INVALID_FILE_EXTENSION_CODE, nil),
+	}
+}
+
+type validateFileCountRule struct {
+	min int
+	max int
+	err error
+}
+
+func (v *validateFileCountRule) Validate(value any) error {
+	files, err := fileHeaders(value)
+	if err != nil {
+		return err
+	}
+
+	if len(files) < v.min || (v.max > 0 && len(files) > v.max) {
+		return v.err
+	}
+
+	return nil
+}
+
+func (v *validateFileCountRule) Error(message string) *validateFileCountRule {
+	v.err = errors.New(message)
+	return v
+}
+
+type validateFileSizeRule struct {
+	maxBytes int64
+	err      error
+}
+
+func (v *validateFileSizeRule) Validate(value any) error {
+	files, err := fileHeaders(value)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.Size > v.maxBytes {
+			return v.err
+		}
+	}
+
+	return nil
+}
+
+func (v *validateFileSizeRule) Error(message string) *validateFileSizeRule {
+	v.err = errors.New(message)
+	return v
+}
+
+type validateFileTypeRule struct {
+	mimeTypes []string
+	err       error
+}
+
+func (v *validateFileTypeRule) Validate(value any) error {
+	files, err := fileHeaders(value)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		contentType, err := DetectFileContentType(file)
+		if err != nil {
+			return validation.NewInternalError(err)
+		}
+
+		allowed := false
+
+		for _, mimeType := range v.mimeTypes {
+			if mt, _, _ := mime.ParseMediaType(mimeType); strings.EqualFold(mt, contentType) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return v.err
+		}
+	}
+
+	return nil
+}
+
+func (v *validateFileTypeRule) Error(message string) *validateFileTypeRule {
+	v.err = errors.New(message)
+	return v
+}
+
+type validateFileExtensionRule struct {
+	exts []string
+	err  error
+}
+
+func (v *validateFileExtensionRule) Validate(value any) error {
+	files, err := fileHeaders(value)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		ext := filepath.Ext(file.Filename)
+		allowed := false
+
+		for _, e := range v.exts {
+			if strings.EqualFold(ext, e) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return v.err
+		}
+	}
+
+	return nil
+}
+
+func (v *validateFileExtensionRule) Error(message string) *validateFileExtensionRule {
+	v.err = errors.New(message)
+	return v
+}
+
+// DetectFileContentType sniffs media type of file from its first 512 bytes
+func DetectFileContentType(file *multipart.FileHeader) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "", err
+	}
+
+	return mediaType, nil
+}
+
+func fileHeaders(value any) ([]*multipart.FileHeader, error) {
+	switch val := value.(type) {
+	case nil:
+		return nil, nil
+	case []*multipart.FileHeader:
+		return val, nil
+	case *multipart.FileHeader:
+		if val == nil {
+			return nil, nil
+		}
+
+		return []*multipart.FileHeader{val}, nil
+	default:
+		return nil, errors.New("Must be *multipart.FileHeader or []*multipart.FileHeader type")
+	}
+}
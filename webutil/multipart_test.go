@@ -0,0 +1,201 @@
+package webutil
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newMultipartRequest(t *testing.T, form string, files map[string][]byte) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if form != "" {
+		if err := writer.WriteField("form", form); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, content := range files {
+		part, err := writer.CreateFormFile("files", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = part.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/url", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestParseMultipartRequestUnitTest(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
+	var form item
+
+	req := newMultipartRequest(t, `{"name": "foo"}`, map[string][]byte{"image.png": testPNG})
+
+	mr, err := ParseMultipartRequest(httptest.NewRecorder(), req, MultipartOptions{})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	defer mr.RemoveAll()
+
+	if err = mr.DecodeForm("form", &form); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if form.Name != "foo" {
+		t.Errorf("should have name 'foo'; got '%s'\n", form.Name)
+	}
+	if len(mr.Files("files")) != 1 {
+		t.Errorf("should have 1 file; got %d\n", len(mr.Files("files")))
+	}
+
+	if err = mr.DecodeForm("missing", &form); !errors.Is(err, ErrBodyRequired) {
+		t.Errorf("should have ErrBodyRequired; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	mr, err = ParseMultipartRequest(
+		httptest.NewRecorder(),
+		newMultipartRequest(t, `{"name": "foo", "foo": 1}`, nil),
+		MultipartOptions{DecodeOptions: DecodeOptions{DisallowUnknownFields: true}},
+	)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	var decodeErr *DecodeError
+
+	if err = mr.DecodeForm("form", &form); !errors.As(err, &decodeErr) {
+		t.Errorf("should have *DecodeError; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	req = newMultipartRequest(t, `{}`, map[string][]byte{"image.png": bytes.Repeat([]byte("a"), 1024)})
+
+	if _, err = ParseMultipartRequest(httptest.NewRecorder(), req, MultipartOptions{MaxBodyBytes: 512}); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("should have ErrBodyTooLarge; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	req = httptest.NewRequest(http.MethodPost, "/url", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", JSON_CONTENT_HEADER)
+
+	if _, err = ParseMultipartRequest(httptest.NewRecorder(), req, MultipartOptions{}); !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("should have ErrUnsupportedContentType; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	req = newMultipartRequest(t, "", map[string][]byte{"large.png": bytes.Repeat([]byte("a"), 4096)})
+
+	if mr, err = ParseMultipartRequest(httptest.NewRecorder(), req, MultipartOptions{MaxMemory: 1024}); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	file, err := mr.Files("files")[0].Open()
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	osFile, ok := file.(*os.File)
+	if !ok {
+		t.Errorf("large file should be spooled to disk\n")
+	}
+
+	file.Close()
+
+	if err = mr.RemoveAll(); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if ok {
+		if _, err = os.Stat(osFile.Name()); !os.IsNotExist(err) {
+			t.Errorf("temp file should be removed\n")
+		}
+	}
+}
+
+func TestFileRulesUnitTest(t *testing.T) {
+	fv := &FormValidation{}
+
+	req := newMultipartRequest(t, "", map[string][]byte{
+		"image.png": testPNG,
+		"notes.txt": []byte("plain text"),
+	})
+
+	mr, err := ParseMultipartRequest(httptest.NewRecorder(), req, MultipartOptions{})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	defer mr.RemoveAll()
+
+	files := mr.Files("files")
+
+	var png, txt *multipart.FileHeader
+
+	for _, file := range files {
+		if file.Filename == "image.png" {
+			png = file
+		} else {
+			txt = file
+		}
+	}
+
+	tests := []struct {
+		name   string
+		value  any
+		rule   validation.Rule
+		errMsg string
+	}{
+		{"count valid", files, fv.ValidateFileCount(1, 2), ""},
+		{"count too many", files, fv.ValidateFileCount(0, 1), INVALID_FILE_COUNT_TXT},
+		{"count too few", files, fv.ValidateFileCount(3, 0), INVALID_FILE_COUNT_TXT},
+		{"size valid", files, fv.ValidateFileSize(1024), ""},
+		{"size too large", files, fv.ValidateFileSize(12), FILE_TOO_LARGE_TXT},
+		{"type valid", png, fv.ValidateFileType(PNG_CONTENT_HEADER), ""},
+		{"type text", txt, fv.ValidateFileType(TEXT_CONTENT_HEADER), ""},
+		{"type invalid", files, fv.ValidateFileType(PNG_CONTENT_HEADER, JPG_CONTENT_HEADER), INVALID_FILE_TYPE_TXT},
+		{"type custom", txt, fv.ValidateFileType(PDF_CONTENT_HEADER).Error("pdf only"), "pdf only"},
+		{"ext valid", files, fv.ValidateFileExtension(".PNG", ".txt"), ""},
+		{"ext invalid", files, fv.ValidateFileExtension(".png"), INVALID_FILE_EXTENSION_TXT},
+	}
+
+	for _, test := range tests {
+		err = validation.Validate(test.value, test.rule)
+
+		if test.errMsg == "" {
+			if err != nil {
+				t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+			}
+		} else if err == nil || err.Error() != test.errMsg {
+			t.Errorf("%s: should have error '%s'; got %v\n", test.name, test.errMsg, err)
+		}
+	}
+
+	if err = validation.Validate("foo", fv.ValidateFileSize(1)); err == nil {
+		t.Errorf("should have error\n")
+	}
+}
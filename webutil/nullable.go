@@ -0,0 +1,430 @@
+package webutil
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// The types below share the same behavior
+//
+// - JSON "null" and "" unmarshal to the empty value, except "" for
+// Nullable[string], and the empty value marshals to "null"
+// - Text "" unmarshals to the empty value and the empty value
+// marshals to ""
+// - Scanning NULL sets the empty value and the empty value is
+// stored as NULL, except ID which is stored as 0
+// - The empty value fails both RequiredRule and validation.Required
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- TYPES ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// ID is uint64 id which is encoded as a json string as javascript
+// can't safely represent integers above 2^53
+type ID uint64
+
+// UUID wraps uuid.UUID where uuid.Nil is treated as empty
+type UUID uuid.UUID
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// NullInt64 is Int64 that may be null
+type NullInt64 struct {
+	Int64 Int64
+	Valid bool
+}
+
+// Nullable is value of T that may be null
+//
+// Scanning delegates to T if *T implements sql.Scanner else uses
+// the same conversions as database/sql and Value delegates to T if
+// T implements driver.Valuer
+type Nullable[T any] struct {
+	V     T
+	Valid bool
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewNullInt64 returns valid NullInt64 of i
+func NewNullInt64(i int64) NullInt64 {
+	return NullInt64{Int64: Int64(i), Valid: true}
+}
+
+// NewNullable returns valid Nullable of v
+func NewNullable[T any](v T) Nullable[T] {
+	return Nullable[T]{V: v, Valid: true}
+}
+
+// isJSONEmpty determines if json value is null or empty string
+func isJSONEmpty(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	return bytes.Equal(b, []byte("null")) || bytes.Equal(b, []byte(`""`))
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- NULLINT64 --------------------------
+//////////////////////////////////////////////////////////////////
+
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return n.Int64.MarshalJSON()
+}
+
+func (n *NullInt64) UnmarshalJSON(b []byte) error {
+	if isJSONEmpty(b) {
+		*n = NullInt64{}
+		return nil
+	}
+
+	if err := n.Int64.UnmarshalJSON(b); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+func (n NullInt64) MarshalText() ([]byte, error) {
+	if !n.Valid {
+		return []byte{}, nil
+	}
+
+	return n.Int64.MarshalText()
+}
+
+func (n *NullInt64) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*n = NullInt64{}
+		return nil
+	}
+
+	if err := n.Int64.UnmarshalText(text); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner interface
+func (n *NullInt64) Scan(value any) error {
+	if value == nil {
+		*n = NullInt64{}
+		return nil
+	}
+
+	if err := n.Int64.Scan(value); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer interface
+func (n NullInt64) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	return int64(n.Int64), nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- NULLABLE ---------------------------
+//////////////////////////////////////////////////////////////////
+
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(n.V)
+}
+
+// UnmarshalJSON sets n to null for json "null" and for "" unless
+// T is string
+func (n *Nullable[T]) UnmarshalJSON(b []byte) error {
+	if _, isStr := any(n.V).(string); bytes.Equal(bytes.TrimSpace(b), []byte("null")) || (!isStr && isJSONEmpty(b)) {
+		*n = Nullable[T]{}
+		return nil
+	}
+
+	if err := json.Unmarshal(b, &n.V); err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+func (n Nullable[T]) MarshalText() ([]byte, error) {
+	if !n.Valid {
+		return []byte{}, nil
+	}
+
+	switch val := any(n.V).(type) {
+	case encoding.TextMarshaler:
+		return val.MarshalText()
+	case string:
+		return []byte(val), nil
+	default:
+		return json.Marshal(n.V)
+	}
+}
+
+func (n *Nullable[T]) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*n = Nullable[T]{}
+		return nil
+	}
+
+	var err error
+
+	switch val := any(&n.V).(type) {
+	case encoding.TextUnmarshaler:
+		err = val.UnmarshalText(text)
+	case *string:
+		*val = string(text)
+	default:
+		err = json.Unmarshal(text, &n.V)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n.Valid = true
+	return nil
+}
+
+// Scan implements sql.Scanner interface
+func (n *Nullable[T]) Scan(value any) error {
+	if value == nil {
+		*n = Nullable[T]{}
+		return nil
+	}
+
+	if scanner, ok := any(&n.V).(sql.Scanner); ok {
+		if err := scanner.Scan(value); err != nil {
+			return err
+		}
+
+		n.Valid = true
+		return nil
+	}
+
+	var null sql.Null[T]
+
+	if err := null.Scan(value); err != nil {
+		return err
+	}
+
+	n.V, n.Valid = null.V, null.Valid
+	return nil
+}
+
+// Value implements driver.Valuer interface
+func (n Nullable[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+
+	if valuer, ok := any(n.V).(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(n.V)
+}
+
+//////////////////////////////////////////////////////////////////
+//----------------------------- ID -----------------------------
+//////////////////////////////////////////////////////////////////
+
+func (i ID) String() string {
+	return strconv.FormatUint(uint64(i), INT_BASE)
+}
+
+// MarshalJSON returns id as json string, 0 is returned as "null"
+func (i ID) MarshalJSON() ([]byte, error) {
+	if i == 0 {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON accepts id as json string or number
+func (i *ID) UnmarshalJSON(b []byte) error {
+	if isJSONEmpty(b) {
+		*i = 0
+		return nil
+	}
+
+	var s string
+
+	if err := json.Unmarshal(b, &s); err == nil {
+		return i.UnmarshalText([]byte(s))
+	}
+
+	return json.Unmarshal(b, (*uint64)(i))
+}
+
+func (i ID) MarshalText() ([]byte, error) {
+	if i == 0 {
+		return []byte{}, nil
+	}
+
+	return []byte(i.String()), nil
+}
+
+func (i *ID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*i = 0
+		return nil
+	}
+
+	val, err := strconv.ParseUint(string(text), INT_BASE, INT_BIT_SIZE)
+	if err != nil {
+		return fmt.Errorf("failed to parse ID: %w", err)
+	}
+
+	*i = ID(val)
+	return nil
+}
+
+// Scan implements sql.Scanner interface
+func (i *ID) Scan(value any) error {
+	switch val := value.(type) {
+	case nil:
+		*i = 0
+	case int64:
+		if val < 0 {
+			return errors.Errorf("webutil: ID can't be negative; got %d", val)
+		}
+
+		*i = ID(val)
+	case string:
+		return i.UnmarshalText([]byte(val))
+	case []byte:
+		return i.UnmarshalText(val)
+	default:
+		return errors.New("webutil: Invalid data type for ID")
+	}
+
+	return nil
+}
+
+// Value implements driver.Valuer interface
+//
+// Returns error if id is larger than max int64 as
+// database drivers only support int64
+func (i ID) Value() (driver.Value, error) {
+	if uint64(i) > math.MaxInt64 {
+		return nil, errors.Errorf("webutil: ID %d overflows int64", uint64(i))
+	}
+
+	return int64(i), nil
+}
+
+//////////////////////////////////////////////////////////////////
+//---------------------------- UUID ----------------------------
+//////////////////////////////////////////////////////////////////
+
+func (u UUID) String() string {
+	return uuid.UUID(u).String()
+}
+
+// IsNil determines if u is uuid.Nil
+func (u UUID) IsNil() bool {
+	return uuid.UUID(u) == uuid.Nil
+}
+
+func (u UUID) MarshalJSON() ([]byte, error) {
+	if u.IsNil() {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(u.String())
+}
+
+func (u *UUID) UnmarshalJSON(b []byte) error {
+	if isJSONEmpty(b) {
+		*u = UUID(uuid.Nil)
+		return nil
+	}
+
+	var s string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	return u.UnmarshalText([]byte(s))
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	if u.IsNil() {
+		return []byte{}, nil
+	}
+
+	return []byte(u.String()), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*u = UUID(uuid.Nil)
+		return nil
+	}
+
+	id, err := uuid.ParseBytes(text)
+	if err != nil {
+		return err
+	}
+
+	*u = UUID(id)
+	return nil
+}
+
+// Scan implements sql.Scanner interface
+func (u *UUID) Scan(value any) error {
+	if value == nil {
+		*u = UUID(uuid.Nil)
+		return nil
+	}
+
+	var id uuid.UUID
+
+	if err := id.Scan(value); err != nil {
+		return err
+	}
+
+	*u = UUID(id)
+	return nil
+}
+
+// Value implements driver.Valuer interface
+func (u UUID) Value() (driver.Value, error) {
+	if u.IsNil() {
+		return nil, nil
+	}
+
+	return u.String(), nil
+}
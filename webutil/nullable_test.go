@@ -0,0 +1,249 @@
+package webutil
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/google/uuid"
+)
+
+func TestInt64UnitTest(t *testing.T) {
+	var i Int64 = 5
+
+	if err := json.Unmarshal([]byte(`""`), &i); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if i != 0 {
+		t.Errorf("empty string should set 0; got %d\n", i)
+	}
+
+	scans := []any{int64(7), "7", []byte("7")}
+
+	for _, scan := range scans {
+		if err := i.Scan(scan); err != nil {
+			t.Fatalf("should not have error; got %s\n", err.Error())
+		}
+		if i != 7 {
+			t.Errorf("%v: should have 7; got %d\n", scan, i)
+		}
+	}
+
+	if err := i.Scan(nil); err != nil || i != 0 {
+		t.Errorf("nil should set 0; got %d %v\n", i, err)
+	}
+	if err := i.Scan(1.5); err == nil {
+		t.Errorf("should have error\n")
+	}
+
+	var valuer driver.Valuer = Int64(9)
+
+	if val, _ := valuer.Value(); val != int64(9) {
+		t.Errorf("should have 9; got %v\n", val)
+	}
+}
+
+func TestNullInt64UnitTest(t *testing.T) {
+	var n NullInt64
+
+	for _, input := range []string{`"12"`, `12`} {
+		if err := json.Unmarshal([]byte(input), &n); err != nil {
+			t.Fatalf("should not have error; got %s\n", err.Error())
+		}
+		if !n.Valid || n.Int64 != 12 {
+			t.Errorf("%s: should have valid 12; got %+v\n", input, n)
+		}
+	}
+
+	b, _ := json.Marshal(n)
+	if string(b) != `"12"` {
+		t.Errorf(`should have "12"; got %s`+"\n", string(b))
+	}
+
+	for _, input := range []string{`null`, `""`} {
+		n = NewNullInt64(1)
+
+		if err := json.Unmarshal([]byte(input), &n); err != nil {
+			t.Fatalf("should not have error; got %s\n", err.Error())
+		}
+		if n.Valid {
+			t.Errorf("%s: should not be valid\n", input)
+		}
+	}
+
+	b, _ = json.Marshal(n)
+	if string(b) != `null` {
+		t.Errorf("should have null; got %s\n", string(b))
+	}
+
+	if err := n.Scan([]byte("3")); err != nil || !n.Valid || n.Int64 != 3 {
+		t.Errorf("should have valid 3; got %+v %v\n", n, err)
+	}
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Errorf("should not be valid; got %+v %v\n", n, err)
+	}
+	if val, _ := n.Value(); val != nil {
+		t.Errorf("should have nil value; got %v\n", val)
+	}
+	if err := n.UnmarshalText([]byte("4")); err != nil || !n.Valid || n.Int64 != 4 {
+		t.Errorf("should have valid 4; got %+v %v\n", n, err)
+	}
+	if text, _ := n.MarshalText(); string(text) != "4" {
+		t.Errorf("should have text 4; got %s\n", string(text))
+	}
+}
+
+func TestNullableUnitTest(t *testing.T) {
+	var s Nullable[string]
+
+	if err := json.Unmarshal([]byte(`""`), &s); err != nil || !s.Valid || s.V != "" {
+		t.Errorf("empty string should be valid; got %+v %v\n", s, err)
+	}
+	if err := json.Unmarshal([]byte(`null`), &s); err != nil || s.Valid {
+		t.Errorf("null should not be valid; got %+v %v\n", s, err)
+	}
+
+	var n Nullable[int]
+
+	if err := json.Unmarshal([]byte(`""`), &n); err != nil || n.Valid {
+		t.Errorf("empty string should not be valid; got %+v %v\n", n, err)
+	}
+	if err := json.Unmarshal([]byte(`5`), &n); err != nil || !n.Valid || n.V != 5 {
+		t.Errorf("should have valid 5; got %+v %v\n", n, err)
+	}
+	if val, err := n.Value(); err != nil || val != int64(5) {
+		t.Errorf("should have int64 5; got %v %v\n", val, err)
+	}
+	if err := n.Scan([]byte("6")); err != nil || !n.Valid || n.V != 6 {
+		t.Errorf("should have valid 6; got %+v %v\n", n, err)
+	}
+	if err := n.UnmarshalText([]byte("7")); err != nil || n.V != 7 {
+		t.Errorf("should have 7; got %+v %v\n", n, err)
+	}
+	if text, _ := n.MarshalText(); string(text) != "7" {
+		t.Errorf("should have text 7; got %s\n", string(text))
+	}
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Errorf("should not be valid; got %+v %v\n", n, err)
+	}
+	if b, _ := json.Marshal(n); string(b) != "null" {
+		t.Errorf("should have null; got %s\n", string(b))
+	}
+
+	// Delegates to Int64 scanner and valuer
+	var i Nullable[Int64]
+
+	if err := i.Scan("8"); err != nil || !i.Valid || i.V != 8 {
+		t.Errorf("should have valid 8; got %+v %v\n", i, err)
+	}
+	if b, _ := json.Marshal(i); string(b) != `"8"` {
+		t.Errorf(`should have "8"; got %s`+"\n", string(b))
+	}
+}
+
+func TestIDUnitTest(t *testing.T) {
+	var id ID
+
+	for _, input := range []string{`"18446744073709551615"`, `18446744073709551615`} {
+		if err := json.Unmarshal([]byte(input), &id); err != nil {
+			t.Fatalf("should not have error; got %s\n", err.Error())
+		}
+		if uint64(id) != 18446744073709551615 {
+			t.Errorf("%s: should have max uint64; got %d\n", input, id)
+		}
+	}
+
+	if _, err := id.Value(); err == nil {
+		t.Errorf("should have overflow error\n")
+	}
+	if err := json.Unmarshal([]byte(`"-1"`), &id); err == nil {
+		t.Errorf("should have error\n")
+	}
+	if err := id.Scan(int64(-1)); err == nil {
+		t.Errorf("should have error\n")
+	}
+	if err := id.Scan([]byte("10")); err != nil || id != 10 {
+		t.Errorf("should have 10; got %d %v\n", id, err)
+	}
+	if b, _ := json.Marshal(id); string(b) != `"10"` {
+		t.Errorf(`should have "10"; got %s`+"\n", string(b))
+	}
+	if val, _ := id.Value(); val != int64(10) {
+		t.Errorf("should have 10; got %v\n", val)
+	}
+	if err := id.Scan(nil); err != nil || id != 0 {
+		t.Errorf("should have 0; got %d %v\n", id, err)
+	}
+	if b, _ := json.Marshal(id); string(b) != `null` {
+		t.Errorf("should have null; got %s\n", string(b))
+	}
+}
+
+func TestUUIDUnitTest(t *testing.T) {
+	var u UUID
+
+	str := "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+	if err := json.Unmarshal([]byte(`"`+str+`"`), &u); err != nil || u.String() != str {
+		t.Errorf("should have %s; got %s %v\n", str, u.String(), err)
+	}
+	if val, _ := u.Value(); val != str {
+		t.Errorf("should have value %s; got %v\n", str, val)
+	}
+	if err := json.Unmarshal([]byte(`""`), &u); err != nil || !u.IsNil() {
+		t.Errorf("should be nil; got %s %v\n", u.String(), err)
+	}
+	if err := json.Unmarshal([]byte(`"foo"`), &u); err == nil {
+		t.Errorf("should have error\n")
+	}
+	if err := u.Scan([]byte(str)); err != nil || u.String() != str {
+		t.Errorf("should have %s; got %s %v\n", str, u.String(), err)
+	}
+	if err := u.Scan(nil); err != nil || !u.IsNil() {
+		t.Errorf("should be nil; got %s %v\n", u.String(), err)
+	}
+	if val, _ := u.Value(); val != nil {
+		t.Errorf("should have nil value; got %v\n", val)
+	}
+	if b, _ := json.Marshal(u); string(b) != "null" {
+		t.Errorf("should have null; got %s\n", string(b))
+	}
+}
+
+func TestNullTypesRequiredUnitTest(t *testing.T) {
+	empty := []any{
+		NullInt64{},
+		&NullInt64{},
+		Nullable[string]{},
+		Nullable[Int64]{},
+		ID(0),
+		UUID{},
+	}
+
+	for _, val := range empty {
+		if err := validation.Validate(val, validation.Required); err == nil {
+			t.Errorf("%T: validation.Required should have error\n", val)
+		}
+		if err := validation.Validate(val, RequiredRule); err == nil {
+			t.Errorf("%T: RequiredRule should have error\n", val)
+		}
+	}
+
+	set := []any{
+		NewNullInt64(1),
+		NewNullable("foo"),
+		NewNullable(Int64(1)),
+		ID(1),
+		UUID(uuid.New()),
+	}
+
+	for _, val := range set {
+		if err := validation.Validate(val, validation.Required); err != nil {
+			t.Errorf("%T: validation.Required should not have error; got %s\n", val, err.Error())
+		}
+		if err := validation.Validate(val, RequiredRule); err != nil {
+			t.Errorf("%T: RequiredRule should not have error; got %s\n", val, err.Error())
+		}
+	}
+}
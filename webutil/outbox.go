@@ -0,0 +1,657 @@
+package webutil
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// OutboxConfig is config struct used in the initialization
+// of *Outbox
+type OutboxConfig struct {
+	// DBType should be one of POSTGRES_DRIVER, MYSQL_DRIVER or SQLITE_DRIVER
+	// and determines the bind vars used
+	DBType string
+
+	// TableName is the table messages are stored in
+	//
+	// Default: DEFAULT_OUTBOX_TABLE
+	TableName string
+
+	// Workers is number of messages sent concurrently
+	//
+	// Default: 4
+	Workers int
+
+	// BatchSize is max number of messages claimed per poll
+	//
+	// Default: 10
+	BatchSize int
+
+	// PollInterval is how long to wait between polls when
+	// there are no more messages ready to send
+	//
+	// Default: 5 seconds
+	PollInterval time.Duration
+
+	// MaxAttempts is number of send attempts, including ones that
+	// never finished, before message is dead-lettered with OUTBOX_STATUS_FAILED
+	//
+	// Default: 5
+	MaxAttempts int
+
+	// BaseBackoff is wait before first retry which doubles
+	// after each failed attempt
+	//
+	// Default: 30 seconds
+	BaseBackoff time.Duration
+
+	// MaxBackoff is max wait between retries
+	//
+	// Default: 1 hour
+	MaxBackoff time.Duration
+
+	// ClaimTimeout is how long a message can be claimed before it is
+	// considered abandoned, such as by a crashed process, and reclaimed
+	//
+	// Default: 10 minutes
+	ClaimTimeout time.Duration
+
+	// Logger is called with errors of background workers
+	//
+	// Default: log.Printf
+	Logger func(err error)
+}
+
+// OutboxMessage is EmailMessage stored in outbox
+type OutboxMessage struct {
+	ID            string
+	Message       EmailMessage
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// decodeErr is error decoding stored message which
+	// dead-letters message instead of sending it
+	decodeErr error
+}
+
+// Outbox implements EmailSender by storing messages in database and
+// delivering them with the wrapped EmailSender from background workers
+// so requests don't wait on, or fail because of, email providers
+//
+// Messages that fail are retried with exponential backoff until
+// MaxAttempts and then dead-lettered where they can be listed
+// with Failed and retried with Requeue
+type Outbox struct {
+	db     Database
+	sender EmailSender
+	config OutboxConfig
+
+	mu      sync.Mutex
+	started bool
+	stop    chan struct{}
+	abort   chan struct{}
+	wg      sync.WaitGroup
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewOutbox returns *Outbox that delivers messages with sender
+//
+// Will return error if DBType is not supported
+func NewOutbox(db Database, sender EmailSender, config OutboxConfig) (*Outbox, error) {
+	switch config.DBType {
+	case POSTGRES_DRIVER, MYSQL_DRIVER, SQLITE_DRIVER:
+	default:
+		return nil, errors.WithStack(fmt.Errorf("webutil: unsupported outbox db type %q", config.DBType))
+	}
+
+	if config.TableName == "" {
+		config.TableName = DEFAULT_OUTBOX_TABLE
+	}
+	if config.Workers < 1 {
+		config.Workers = 4
+	}
+	if config.BatchSize < 1 {
+		config.BatchSize = 10
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 5
+	}
+	if config.BaseBackoff == 0 {
+		config.BaseBackoff = 30 * time.Second
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = time.Hour
+	}
+	if config.ClaimTimeout == 0 {
+		config.ClaimTimeout = 10 * time.Minute
+	}
+	if config.Logger == nil {
+		config.Logger = func(err error) {
+			log.Printf("webutil: %+v", err)
+		}
+	}
+
+	return &Outbox{
+		db:     db,
+		sender: sender,
+		config: config,
+	}, nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// CreateTable creates outbox table if it does not exist
+func (o *Outbox) CreateTable(ctx context.Context) error {
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s ("+
+			"id VARCHAR(36) NOT NULL PRIMARY KEY, "+
+			"message TEXT NOT NULL, "+
+			"status VARCHAR(20) NOT NULL, "+
+			"attempts INT NOT NULL DEFAULT 0, "+
+			"last_error TEXT, "+
+			"next_attempt_at TIMESTAMP NOT NULL, "+
+			"created_at TIMESTAMP NOT NULL, "+
+			"updated_at TIMESTAMP NOT NULL)",
+		o.config.TableName,
+	)
+
+	if _, err := o.db.ExecContext(ctx, query); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// SendEmail stores msgs to be sent by background workers
+func (o *Outbox) SendEmail(msgs ...EmailMessage) error {
+	return o.Enqueue(context.Background(), msgs...)
+}
+
+// Enqueue stores msgs within a single transaction to be sent
+// by background workers
+func (o *Outbox) Enqueue(ctx context.Context, msgs ...EmailMessage) (err error) {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = errors.WithStack(tx.Commit())
+		}
+	}()
+
+	return o.EnqueueTx(ctx, tx, msgs...)
+}
+
+// EnqueueTx stores msgs using tx so messages are only sent if
+// the rest of the transaction commits
+func (o *Outbox) EnqueueTx(ctx context.Context, tx qrm.Executable, msgs ...EmailMessage) error {
+	query := Rebind(o.bindVar(), fmt.Sprintf(
+		"INSERT INTO %s (id, message, status, attempts, next_attempt_at, created_at, updated_at) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?)",
+		o.config.TableName,
+	))
+
+	for _, msg := range msgs {
+		if err := validateEmailMessage(msg); err != nil {
+			return err
+		}
+
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		now := timeNow().UTC()
+
+		if _, err = tx.ExecContext(
+			ctx,
+			query,
+			NewV7UUIDString(),
+			string(b),
+			OUTBOX_STATUS_PENDING,
+			0,
+			now,
+			now,
+			now,
+		); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// Start starts background workers which run until Shutdown
+func (o *Outbox) Start() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.started {
+		return errors.New("webutil: outbox already started")
+	}
+
+	stop := make(chan struct{})
+	abort := make(chan struct{})
+	jobs := make(chan OutboxMessage)
+
+	o.started = true
+	o.stop = stop
+	o.abort = abort
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer close(jobs)
+
+		// Cancels in progress poll queries once shutting down
+		go func() {
+			<-stop
+			cancel()
+		}()
+
+		o.poll(ctx, stop, jobs)
+	}()
+
+	for i := 0; i < o.config.Workers; i++ {
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+
+			for msg := range jobs {
+				// Messages are left claimed once shutdown gives up
+				select {
+				case <-abort:
+					continue
+				default:
+				}
+
+				if err := o.deliver(context.Background(), msg); err != nil {
+					o.config.Logger(err)
+				}
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Shutdown stops polling for messages and waits for messages already
+// claimed to be sent or until ctx is done
+//
+// Once ctx is done, messages being sent still finish in the background
+// so they aren't sent twice, where claimed messages that haven't started
+// are no longer sent and are reclaimed after ClaimTimeout
+func (o *Outbox) Shutdown(ctx context.Context) error {
+	o.mu.Lock()
+
+	if !o.started {
+		o.mu.Unlock()
+		return nil
+	}
+
+	o.started = false
+	abort := o.abort
+	close(o.stop)
+	o.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		close(abort)
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// ProcessPending claims and sends a single batch of messages ready to
+// be sent and returns number of messages claimed
+//
+// Every claimed message is delivered even if others fail, where errors
+// of each are joined into returned error
+//
+// Start should be used instead in most cases but this can be used
+// to send messages from a cron job or within tests
+func (o *Outbox) ProcessPending(ctx context.Context) (int, error) {
+	var errs []error
+
+	// Messages claimed before an error are still delivered
+	// rather than waiting on ClaimTimeout
+	msgs, err := o.claim(ctx)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, msg := range msgs {
+		if err = o.deliver(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(msgs), stderrors.Join(errs...)
+}
+
+// Failed returns dead-lettered messages, oldest first
+//
+// If limit is less than 1, every message after offset is returned
+func (o *Outbox) Failed(ctx context.Context, limit, offset int) ([]OutboxMessage, error) {
+	var page string
+
+	switch {
+	case limit > 0:
+		page = fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	case offset < 1:
+	case o.config.DBType == MYSQL_DRIVER:
+		// Mysql and sqlite require LIMIT to use OFFSET
+		page = fmt.Sprintf(" LIMIT 18446744073709551615 OFFSET %d", offset)
+	case o.config.DBType == SQLITE_DRIVER:
+		page = fmt.Sprintf(" LIMIT -1 OFFSET %d", offset)
+	default:
+		page = fmt.Sprintf(" OFFSET %d", offset)
+	}
+
+	rows, err := o.db.QueryContext(
+		ctx,
+		Rebind(o.bindVar(), fmt.Sprintf(
+			"SELECT %s FROM %s WHERE status = ? ORDER BY created_at%s",
+			outboxColumns,
+			o.config.TableName,
+			page,
+		)),
+		OUTBOX_STATUS_FAILED,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return scanOutboxMessages(rows)
+}
+
+// Requeue resets dead-lettered messages of ids so they are sent
+// again with a full set of attempts and returns number requeued
+//
+// Every dead-lettered message is requeued if no ids are passed
+func (o *Outbox) Requeue(ctx context.Context, ids ...string) (int64, error) {
+	now := timeNow().UTC()
+	query := fmt.Sprintf(
+		"UPDATE %s SET status = ?, attempts = 0, last_error = NULL, next_attempt_at = ?, updated_at = ? WHERE status = ?",
+		o.config.TableName,
+	)
+	args := []any{OUTBOX_STATUS_PENDING, now, now, OUTBOX_STATUS_FAILED}
+
+	if len(ids) > 0 {
+		var err error
+
+		if query, args, err = In(query+" AND id IN (?)", append(args, ids)...); err != nil {
+			return 0, errors.WithStack(err)
+		}
+	}
+
+	res, err := o.db.ExecContext(ctx, Rebind(o.bindVar(), query), args...)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	count, err := res.RowsAffected()
+	return count, errors.WithStack(err)
+}
+
+// poll claims messages and passes them to workers until shutdown
+func (o *Outbox) poll(ctx context.Context, stop <-chan struct{}, jobs chan<- OutboxMessage) {
+	for {
+		msgs, err := o.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			o.config.Logger(err)
+		}
+
+		// Claimed messages are always passed to workers so they
+		// are sent before shutdown completes
+		for _, msg := range msgs {
+			jobs <- msg
+		}
+
+		if err == nil && len(msgs) == o.config.BatchSize {
+			select {
+			case <-stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(o.config.PollInterval):
+		}
+	}
+}
+
+// claim marks batch of messages ready to be sent as OUTBOX_STATUS_SENDING
+//
+// Messages are claimed one at a time with conditional update so multiple
+// processes can share the same table without sending a message twice
+func (o *Outbox) claim(ctx context.Context) ([]OutboxMessage, error) {
+	now := timeNow().UTC()
+	claimExpired := now.Add(-o.config.ClaimTimeout)
+	condition := "((status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?))"
+	conditionArgs := []any{OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, claimExpired}
+
+	rows, err := o.db.QueryContext(
+		ctx,
+		Rebind(o.bindVar(), fmt.Sprintf(
+			"SELECT %s FROM %s WHERE %s ORDER BY next_attempt_at LIMIT %d",
+			outboxColumns,
+			o.config.TableName,
+			condition,
+			o.config.BatchSize,
+		)),
+		conditionArgs...,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	msgs, err := scanOutboxMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attempts are counted when claiming so messages that hang or crash a
+	// worker are eventually dead-lettered after being reclaimed
+	query := Rebind(o.bindVar(), fmt.Sprintf(
+		"UPDATE %s SET status = ?, attempts = attempts + 1, updated_at = ? WHERE id = ? AND %s",
+		o.config.TableName,
+		condition,
+	))
+	claimed := make([]OutboxMessage, 0, len(msgs))
+
+	for _, msg := range msgs {
+		res, err := o.db.ExecContext(ctx, query, append([]any{OUTBOX_STATUS_SENDING, now, msg.ID}, conditionArgs...)...)
+		if err != nil {
+			return claimed, errors.WithStack(err)
+		}
+
+		// Another process claimed message first
+		if count, _ := res.RowsAffected(); count != 1 {
+			continue
+		}
+
+		msg.Status = OUTBOX_STATUS_SENDING
+		msg.Attempts++
+		msg.UpdatedAt = now
+		claimed = append(claimed, msg)
+	}
+
+	return claimed, nil
+}
+
+// deliver sends claimed msg and records result
+func (o *Outbox) deliver(ctx context.Context, msg OutboxMessage) error {
+	err := msg.decodeErr
+
+	// Message was reclaimed after its last attempt never finished
+	if err == nil && msg.Attempts > o.config.MaxAttempts {
+		err = fmt.Errorf("webutil: outbox message exceeded %d attempts", o.config.MaxAttempts)
+	}
+
+	if err == nil {
+		if err = o.sender.SendEmail(msg.Message); err != nil {
+			o.config.Logger(errors.Wrapf(err, "webutil: outbox message %s failed to send", msg.ID))
+		}
+	}
+
+	now := timeNow().UTC()
+
+	if err == nil {
+		_, err = o.db.ExecContext(
+			ctx,
+			Rebind(o.bindVar(), fmt.Sprintf(
+				"UPDATE %s SET status = ?, attempts = ?, last_error = NULL, updated_at = ? WHERE id = ?",
+				o.config.TableName,
+			)),
+			OUTBOX_STATUS_SENT,
+			msg.Attempts,
+			now,
+			msg.ID,
+		)
+
+		return errors.WithStack(err)
+	}
+
+	status := OUTBOX_STATUS_PENDING
+
+	if msg.Attempts >= o.config.MaxAttempts || msg.decodeErr != nil {
+		status = OUTBOX_STATUS_FAILED
+	}
+
+	if _, err = o.db.ExecContext(
+		ctx,
+		Rebind(o.bindVar(), fmt.Sprintf(
+			"UPDATE %s SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ? WHERE id = ?",
+			o.config.TableName,
+		)),
+		status,
+		msg.Attempts,
+		err.Error(),
+		now.Add(o.backoff(msg.Attempts)),
+		now,
+		msg.ID,
+	); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// backoff returns wait before next attempt after attempts failed sends
+func (o *Outbox) backoff(attempts int) time.Duration {
+	wait := o.config.BaseBackoff
+
+	for i := 1; i < attempts; i++ {
+		if wait *= 2; wait >= o.config.MaxBackoff {
+			return o.config.MaxBackoff
+		}
+	}
+
+	return wait
+}
+
+func (o *Outbox) bindVar() int {
+	if o.config.DBType == POSTGRES_DRIVER {
+		return DOLLAR_SQL_BIND_VAR
+	}
+
+	return QUESTION_SQL_BIND_VAR
+}
+
+// outboxColumns are columns scanned by scanOutboxMessages
+const outboxColumns = "id, message, status, attempts, last_error, next_attempt_at, created_at, updated_at"
+
+// scanOutboxMessages scans and closes rows selected with outboxColumns
+func scanOutboxMessages(rows *sql.Rows) ([]OutboxMessage, error) {
+	defer rows.Close()
+
+	msgs := make([]OutboxMessage, 0)
+
+	for rows.Next() {
+		var msg OutboxMessage
+		var message string
+		var lastError sql.NullString
+		var nextAttemptAt, createdAt, updatedAt any
+
+		if err := rows.Scan(
+			&msg.ID,
+			&message,
+			&msg.Status,
+			&msg.Attempts,
+			&lastError,
+			&nextAttemptAt,
+			&createdAt,
+			&updatedAt,
+		); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if err := json.Unmarshal([]byte(message), &msg.Message); err != nil {
+			msg.decodeErr = errors.Wrap(err, "webutil: invalid outbox message")
+		}
+
+		for _, t := range []struct {
+			dest  *time.Time
+			value any
+		}{
+			{&msg.NextAttemptAt, nextAttemptAt},
+			{&msg.CreatedAt, createdAt},
+			{&msg.UpdatedAt, updatedAt},
+		} {
+			var err error
+
+			if *t.dest, err = scanFormTime(t.value, time.UTC); err != nil {
+				return nil, err
+			}
+		}
+
+		msg.LastError = lastError.String
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, errors.WithStack(rows.Err())
+}
@@ -0,0 +1,385 @@
+package webutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+type emailSenderFunc func(msgs ...EmailMessage) error
+
+func (e emailSenderFunc) SendEmail(msgs ...EmailMessage) error {
+	return e(msgs...)
+}
+
+func getTestOutboxRows(t *testing.T, now time.Time, rows ...OutboxMessage) *sqlmock.Rows {
+	mockRows := sqlmock.NewRows([]string{
+		"id", "message", "status", "attempts", "last_error", "next_attempt_at", "created_at", "updated_at",
+	})
+
+	for _, row := range rows {
+		b, err := json.Marshal(row.Message)
+		if err != nil {
+			t.Fatalf("err: %s\n", err.Error())
+		}
+
+		mockRows.AddRow(row.ID, string(b), row.Status, row.Attempts, nil, now, now.Format(DATE_TIME_LAYOUT), now)
+	}
+
+	return mockRows
+}
+
+func TestOutboxUnitTest(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	if _, err = NewOutbox(db, nil, OutboxConfig{DBType: "invalid"}); err == nil {
+		t.Errorf("should have error for invalid db type\n")
+	}
+
+	sender := NewMemoryEmailSender()
+
+	var logged []error
+
+	outbox, err := NewOutbox(db, sender, OutboxConfig{
+		DBType: POSTGRES_DRIVER,
+		Logger: func(err error) { logged = append(logged, err) },
+	})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	msg := EmailMessage{
+		From:    EmailAddress{Email: "from@example.com"},
+		To:      []EmailAddress{{Email: "to@example.com"}},
+		Subject: "Hello",
+	}
+
+	selectQuery := regexp.QuoteMeta(
+		"SELECT id, message, status, attempts, last_error, next_attempt_at, created_at, updated_at FROM email_outbox " +
+			"WHERE ((status = $1 AND next_attempt_at <= $2) OR (status = $3 AND updated_at < $4)) ORDER BY next_attempt_at LIMIT 10",
+	)
+	claimQuery := regexp.QuoteMeta(
+		"UPDATE email_outbox SET status = $1, attempts = attempts + 1, updated_at = $2 WHERE id = $3 AND ((status = $4",
+	)
+	sentQuery := regexp.QuoteMeta("UPDATE email_outbox SET status = $1, attempts = $2, last_error = NULL, updated_at = $3 WHERE id = $4")
+	failQuery := regexp.QuoteMeta(
+		"UPDATE email_outbox SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, updated_at = $5 WHERE id = $6",
+	)
+
+	// ---------------------------------------------------------------------
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO email_outbox (id, message, status, attempts, next_attempt_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), OUTBOX_STATUS_PENDING, 0, now, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err = outbox.SendEmail(msg); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	if err = outbox.SendEmail(EmailMessage{}); err == nil {
+		t.Errorf("should have invalid message error\n")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	mock.ExpectQuery(selectQuery).
+		WithArgs(OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnRows(getTestOutboxRows(t, now, OutboxMessage{ID: "1", Message: msg, Status: OUTBOX_STATUS_PENDING}))
+	mock.ExpectExec(claimQuery).
+		WithArgs(OUTBOX_STATUS_SENDING, now, "1", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sentQuery).
+		WithArgs(OUTBOX_STATUS_SENT, 1, now, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if count, err := outbox.ProcessPending(context.Background()); err != nil || count != 1 {
+		t.Errorf("should have 1 sent; got %d %v\n", count, err)
+	}
+	if msgs := sender.SentTo("to@example.com"); len(msgs) != 1 {
+		t.Errorf("should have sent message; got %d\n", len(msgs))
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	sender.SetError(errors.New("provider down"))
+
+	rows := getTestOutboxRows(
+		t,
+		now,
+		OutboxMessage{ID: "2", Message: msg, Status: OUTBOX_STATUS_PENDING, Attempts: 1},
+		OutboxMessage{ID: "3", Message: msg, Status: OUTBOX_STATUS_SENDING, Attempts: 4},
+		OutboxMessage{ID: "4", Message: msg, Status: OUTBOX_STATUS_PENDING},
+		OutboxMessage{ID: "6", Message: msg, Status: OUTBOX_STATUS_SENDING, Attempts: 5},
+	)
+	rows.AddRow("5", "invalid", OUTBOX_STATUS_PENDING, 0, nil, now, now, now)
+
+	mock.ExpectQuery(selectQuery).WillReturnRows(rows)
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "2", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "3", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Claimed by another process
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "4", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "6", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "5", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(failQuery).
+		WithArgs(OUTBOX_STATUS_PENDING, 2, "provider down", now.Add(time.Minute), now, "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(failQuery).
+		WithArgs(OUTBOX_STATUS_FAILED, 5, "provider down", now.Add(8*time.Minute), now, "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Reclaimed after its last attempt so dead-lettered without sending
+	mock.ExpectExec(failQuery).
+		WithArgs(OUTBOX_STATUS_FAILED, 6, "webutil: outbox message exceeded 5 attempts", now.Add(16*time.Minute), now, "6").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(failQuery).
+		WithArgs(OUTBOX_STATUS_FAILED, 1, sqlmock.AnyArg(), now.Add(30*time.Second), now, "5").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if count, err := outbox.ProcessPending(context.Background()); err != nil || count != 4 {
+		t.Errorf("should have 4 claimed; got %d %v\n", count, err)
+	}
+	if len(logged) != 2 || !errors.Is(logged[0], sender.err) {
+		t.Errorf("should log both send errors; got %v\n", logged)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	// Failing to record one message shouldn't stop the rest
+	sender.SetError(nil)
+	recordErr := errors.New("connection lost")
+
+	mock.ExpectQuery(selectQuery).
+		WillReturnRows(getTestOutboxRows(
+			t,
+			now,
+			OutboxMessage{ID: "7", Message: msg, Status: OUTBOX_STATUS_PENDING},
+			OutboxMessage{ID: "8", Message: msg, Status: OUTBOX_STATUS_PENDING},
+		))
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "7", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(claimQuery).WithArgs(OUTBOX_STATUS_SENDING, now, "8", OUTBOX_STATUS_PENDING, now, OUTBOX_STATUS_SENDING, now.Add(-10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(sentQuery).
+		WithArgs(OUTBOX_STATUS_SENT, 1, now, "7").
+		WillReturnError(recordErr)
+	mock.ExpectExec(sentQuery).
+		WithArgs(OUTBOX_STATUS_SENT, 1, now, "8").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if count, err := outbox.ProcessPending(context.Background()); !errors.Is(err, recordErr) || count != 2 {
+		t.Errorf("should have 2 claimed with record error; got %d %v\n", count, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM email_outbox WHERE status = $1 ORDER BY created_at LIMIT 20 OFFSET 0")).
+		WithArgs(OUTBOX_STATUS_FAILED).
+		WillReturnRows(getTestOutboxRows(t, now, OutboxMessage{ID: "3", Message: msg, Status: OUTBOX_STATUS_FAILED, Attempts: 5}))
+
+	failed, err := outbox.Failed(context.Background(), 20, 0)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if len(failed) != 1 || failed[0].ID != "3" || failed[0].Message.Subject != "Hello" || !failed[0].CreatedAt.Equal(now) {
+		t.Errorf("should have failed message; got %+v\n", failed)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM email_outbox WHERE status = $1 ORDER BY created_at") + "$").
+		WithArgs(OUTBOX_STATUS_FAILED).
+		WillReturnRows(getTestOutboxRows(t, now))
+
+	if _, err = outbox.Failed(context.Background(), 0, 0); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta(
+		"UPDATE email_outbox SET status = $1, attempts = 0, last_error = NULL, next_attempt_at = $2, updated_at = $3 WHERE status = $4 AND id IN ($5, $6)",
+	)).
+		WithArgs(OUTBOX_STATUS_PENDING, now, now, OUTBOX_STATUS_FAILED, "3", "5").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if count, err := outbox.Requeue(context.Background(), "3", "5"); err != nil || count != 2 {
+		t.Errorf("should have 2 requeued; got %d %v\n", count, err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("WHERE status = $4")).
+		WithArgs(OUTBOX_STATUS_PENDING, now, now, OUTBOX_STATUS_FAILED).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	if count, err := outbox.Requeue(context.Background()); err != nil || count != 7 {
+		t.Errorf("should have 7 requeued; got %d %v\n", count, err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
+
+func TestOutboxBackoffUnitTest(t *testing.T) {
+	outbox, _ := NewOutbox(nil, nil, OutboxConfig{DBType: SQLITE_DRIVER, BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	expected := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 5: 10 * time.Second, 50: 10 * time.Second}
+
+	for attempts, wait := range expected {
+		if backoff := outbox.backoff(attempts); backoff != wait {
+			t.Errorf("%d: should have %s; got %s\n", attempts, wait, backoff)
+		}
+	}
+}
+
+func TestOutboxShutdownUnitTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	sending := make(chan struct{})
+	release := make(chan struct{})
+
+	sender := emailSenderFunc(func(msgs ...EmailMessage) error {
+		close(sending)
+		<-release
+		return nil
+	})
+
+	outbox, _ := NewOutbox(db, sender, OutboxConfig{DBType: MYSQL_DRIVER, Workers: 2, PollInterval: time.Hour})
+
+	if err = outbox.Shutdown(context.Background()); err != nil {
+		t.Errorf("should not have error when not started; got %s\n", err.Error())
+	}
+
+	msg := EmailMessage{From: EmailAddress{Email: "from@example.com"}, To: []EmailAddress{{Email: "to@example.com"}}}
+
+	mock.ExpectQuery("SELECT (.+) FROM email_outbox").
+		WillReturnRows(getTestOutboxRows(t, time.Now(), OutboxMessage{ID: "1", Message: msg, Status: OUTBOX_STATUS_PENDING}))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE email_outbox SET status = ?, attempts = attempts + 1, updated_at = ? WHERE id = ?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE email_outbox SET status = ?, attempts = ?, last_error = NULL")).
+		WithArgs(OUTBOX_STATUS_SENT, 1, sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err = outbox.Start(); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if err = outbox.Start(); err == nil {
+		t.Errorf("should have already started error\n")
+	}
+
+	<-sending
+
+	shutdown := make(chan error)
+
+	go func() {
+		shutdown <- outbox.Shutdown(context.Background())
+	}()
+
+	select {
+	case <-shutdown:
+		t.Fatalf("should wait for in-flight send\n")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err = <-shutdown; err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
+
+func TestOutboxShutdownTimeoutUnitTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	sending := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	sender := emailSenderFunc(func(msgs ...EmailMessage) error {
+		sending <- struct{}{}
+		<-release
+		return nil
+	})
+
+	outbox, _ := NewOutbox(db, sender, OutboxConfig{DBType: MYSQL_DRIVER, Workers: 1, PollInterval: time.Hour})
+	msg := EmailMessage{From: EmailAddress{Email: "from@example.com"}, To: []EmailAddress{{Email: "to@example.com"}}}
+
+	mock.ExpectQuery("SELECT (.+) FROM email_outbox").
+		WillReturnRows(getTestOutboxRows(
+			t,
+			time.Now(),
+			OutboxMessage{ID: "1", Message: msg, Status: OUTBOX_STATUS_PENDING},
+			OutboxMessage{ID: "2", Message: msg, Status: OUTBOX_STATUS_PENDING},
+		))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE email_outbox SET status = ?, attempts = attempts + 1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE email_outbox SET status = ?, attempts = attempts + 1")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Only message being sent when shutdown gives up is recorded
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE email_outbox SET status = ?, attempts = ?, last_error = NULL")).
+		WithArgs(OUTBOX_STATUS_SENT, 1, sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err = outbox.Start(); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	<-sending
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err = outbox.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("should have deadline error; got %v\n", err)
+	}
+
+	close(release)
+	outbox.wg.Wait()
+
+	if len(sending) != 0 {
+		t.Errorf("should not send claimed message after shutdown gave up\n")
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
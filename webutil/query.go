@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"reflect"
 
@@ -22,6 +23,35 @@ var (
 	// This is to help visualize what the query builder is sending to database to troubleshoot
 	// any queries
 	DebugPrintQueryOutput = false
+
+	// dateOperators are operators that filter a field by date within
+	// timezone and can only be used on fields with CanFilterByDate
+	dateOperators = map[string]bool{
+		"on":           true,
+		"before":       true,
+		"after":        true,
+		"today":        true,
+		"yesterday":    true,
+		"this_week":    true,
+		"this_month":   true,
+		"last_month":   true,
+		"last_7_days":  true,
+		"last_30_days": true,
+	}
+
+	// relativeDateOperators are date operators that don't take a value
+	relativeDateOperators = map[string]bool{
+		"today":        true,
+		"yesterday":    true,
+		"this_week":    true,
+		"this_month":   true,
+		"last_month":   true,
+		"last_7_days":  true,
+		"last_30_days": true,
+	}
+
+	// timeNow returns current time and is replaced within tests
+	timeNow = time.Now
 )
 
 //////////////////////////////////////////////////////////////////
@@ -67,6 +97,21 @@ type QueryConfig struct {
 
 	CanMultiColumnOrder bool
 	CanMultiColumnGroup bool
+
+	// TimezoneParam is query param of IANA timezone date operators
+	// such as "on" and "this_month" are applied in
+	TimezoneParam string
+
+	// Timezone is IANA timezone used by date operators when
+	// TimezoneParam is not set or not sent within request
+	//
+	// Default: "UTC"
+	Timezone string
+
+	// WeekStart is first day of week for the "this_week" operator
+	//
+	// Default: time.Sunday
+	WeekStart time.Weekday
 }
 
 type DataInputParams struct {
@@ -104,6 +149,10 @@ type OperationConfig struct {
 	// CanFilterBy determines whether field can have filters applied
 	CanFilterBy bool
 
+	// CanFilterByDate determines whether date operators, such as "on"
+	// and "last_7_days", can be applied to field along with CanFilterBy
+	CanFilterByDate bool
+
 	// CanSortBy determines whether field can be sorted
 	CanSortBy bool
 
@@ -560,13 +609,48 @@ func GetQueryBuilder(
 			}
 
 			if filter.Value == nil {
-				if filter.Operator != "isnull" && filter.Operator != "isnotnull" {
+				if filter.Operator != "isnull" && filter.Operator != "isnotnull" && !relativeDateOperators[filter.Operator] {
 					return sq.SelectBuilder{}, QueryBuilderError{
 						errorMsg: fmt.Sprintf("field %q does not contain value", filter.Field),
 					}
 				}
 			}
 
+			// Date operators use raw value as ValueOverride is
+			// for values compared directly against field
+			if dateOperators[filter.Operator] {
+				if !dbField.OperationCfg.CanFilterByDate {
+					return sq.SelectBuilder{}, errors.WithStack(
+						QueryBuilderError{errorMsg: fmt.Sprintf("field %q can not be filtered by date", filter.Field)},
+					)
+				}
+
+				dateRange, err := getDateFilterRange(r, filter.Operator, filter.Value, cfg)
+				if err != nil {
+					return sq.SelectBuilder{}, errors.WithStack(
+						QueryBuilderError{errorMsg: fmt.Sprintf("%s for field %q", err.Error(), filter.Field)},
+					)
+				}
+
+				switch filter.Operator {
+				case "before":
+					builder = builder.Where(sq.Lt{
+						dbField.DBField: dateRange.Start,
+					})
+				case "after":
+					builder = builder.Where(sq.GtOrEq{
+						dbField.DBField: dateRange.End,
+					})
+				default:
+					builder = builder.Where(sq.And{
+						sq.GtOrEq{dbField.DBField: dateRange.Start},
+						sq.Lt{dbField.DBField: dateRange.End},
+					})
+				}
+
+				continue
+			}
+
 			fieldValue := filter.Value
 
 			if dbField.ValueOverride != nil {
@@ -729,6 +813,60 @@ func GetQueryBuilder(
 	return builder, nil
 }
 
+// getDateFilterRange returns UTC range of date operator within the timezone
+// of the request, or QueryConfig#Timezone, where "on", "before" and "after"
+// use the date of value and relative operators use the current date
+//
+// "before" is everything before Start and "after" is everything from End
+func getDateFilterRange(r *http.Request, operator string, value any, cfg QueryConfig) (TimeRange, error) {
+	timezone := cfg.Timezone
+
+	if cfg.TimezoneParam != "" {
+		if tz := r.FormValue(cfg.TimezoneParam); tz != "" {
+			timezone = tz
+		}
+	}
+
+	loc, err := formLocation(timezone)
+	if err != nil {
+		return TimeRange{}, errors.New("invalid timezone")
+	}
+
+	if !relativeDateOperators[operator] {
+		str, ok := value.(string)
+		if !ok {
+			return TimeRange{}, errors.New("invalid date value")
+		}
+
+		date, err := parseInLocation(str, loc, formDateLayouts)
+		if err != nil {
+			return TimeRange{}, errors.New("invalid date value")
+		}
+
+		return localRange(date.Year(), date.Month(), date.Day(), 0, 0, 1, loc), nil
+	}
+
+	now := timeNow().In(loc)
+	year, month, day := now.Date()
+
+	switch operator {
+	case "yesterday":
+		return localRange(year, month, day-1, 0, 0, 1, loc), nil
+	case "this_week":
+		return localRange(year, month, day-(int(now.Weekday())-int(cfg.WeekStart)+7)%7, 0, 0, 7, loc), nil
+	case "this_month":
+		return localRange(year, month, 1, 0, 1, 0, loc), nil
+	case "last_month":
+		return localRange(year, month-1, 1, 0, 1, 0, loc), nil
+	case "last_7_days":
+		return localRange(year, month, day-6, 0, 0, 7, loc), nil
+	case "last_30_days":
+		return localRange(year, month, day-29, 0, 0, 30, loc), nil
+	default:
+		return localRange(year, month, day, 0, 0, 1, loc), nil
+	}
+}
+
 func scanColVals(r ColScanner) ([]string, []any, error) {
 	// ignore r.started, since we needn't use reflect for anything.
 	columns, err := r.Columns()
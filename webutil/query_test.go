@@ -2,11 +2,13 @@ package webutil
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 )
@@ -784,3 +786,186 @@ func TestGetQueryBuilder(t *testing.T) {
 		t.Errorf("should not have error; got %s\n", err.Error())
 	}
 }
+
+func TestGetQueryBuilderDateOperatorsUnitTest(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	// 2024-03-10 01:00 in New York
+	timeNow = func() time.Time { return time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC) }
+
+	cfg := QueryConfig{
+		FilterParam:   "filters",
+		TimezoneParam: "tz",
+		Timezone:      "America/New_York",
+		WeekStart:     time.Monday,
+	}
+	dbFields := DbFields{
+		"createdAt": FieldConfig{
+			DBField: "user.created_at",
+			// Date operators shouldn't go through override
+			ValueOverride: func(value any) (any, error) {
+				return nil, errors.New("override")
+			},
+			OperationCfg: OperationConfig{
+				CanFilterBy:     true,
+				CanFilterByDate: true,
+			},
+		},
+		"name": FieldConfig{
+			DBField: "user.name",
+			OperationCfg: OperationConfig{
+				CanFilterBy: true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		timezone string
+		sql      string
+		args     []string
+	}{
+		{
+			"on dst day",
+			Filter{Field: "createdAt", Operator: "on", Value: "03/10/2024"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-10 05:00:00", "2024-03-11 04:00:00"},
+		},
+		{
+			"on request timezone",
+			Filter{Field: "createdAt", Operator: "on", Value: "2024-03-10"},
+			"Asia/Tokyo",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-09 15:00:00", "2024-03-10 15:00:00"},
+		},
+		{
+			"before",
+			Filter{Field: "createdAt", Operator: "before", Value: "2024-03-10"},
+			"",
+			"WHERE user.created_at < ?",
+			[]string{"2024-03-10 05:00:00"},
+		},
+		{
+			"after",
+			Filter{Field: "createdAt", Operator: "after", Value: "2024-03-10"},
+			"",
+			"WHERE user.created_at >= ?",
+			[]string{"2024-03-11 04:00:00"},
+		},
+		{
+			"today",
+			Filter{Field: "createdAt", Operator: "today"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-10 05:00:00", "2024-03-11 04:00:00"},
+		},
+		{
+			"yesterday",
+			Filter{Field: "createdAt", Operator: "yesterday"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-09 05:00:00", "2024-03-10 05:00:00"},
+		},
+		{
+			"this week",
+			Filter{Field: "createdAt", Operator: "this_week"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-04 05:00:00", "2024-03-11 04:00:00"},
+		},
+		{
+			"this month",
+			Filter{Field: "createdAt", Operator: "this_month"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-01 05:00:00", "2024-04-01 04:00:00"},
+		},
+		{
+			"last month",
+			Filter{Field: "createdAt", Operator: "last_month"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-02-01 05:00:00", "2024-03-01 05:00:00"},
+		},
+		{
+			"last 7 days",
+			Filter{Field: "createdAt", Operator: "last_7_days"},
+			"",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-03-04 05:00:00", "2024-03-11 04:00:00"},
+		},
+		{
+			"last 30 days utc",
+			Filter{Field: "createdAt", Operator: "last_30_days"},
+			"UTC",
+			"WHERE (user.created_at >= ? AND user.created_at < ?)",
+			[]string{"2024-02-10 00:00:00", "2024-03-11 00:00:00"},
+		},
+	}
+
+	for _, test := range tests {
+		jsonBytes, _ := json.Marshal([]Filter{test.filter})
+
+		urlVals := url.Values{}
+		urlVals.Add(cfg.FilterParam, string(jsonBytes))
+		urlVals.Add(cfg.TimezoneParam, test.timezone)
+
+		req := httptest.NewRequest(http.MethodGet, "/url?"+urlVals.Encode(), nil)
+
+		builder, err := GetQueryBuilder(req, sq.Select("user.id").From("user"), dbFields, cfg)
+		if err != nil {
+			t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+			continue
+		}
+
+		query, args, _ := builder.ToSql()
+
+		if !strings.HasSuffix(query, test.sql) {
+			t.Errorf("%s: should have '%s'; got '%s'\n", test.name, test.sql, query)
+		}
+		if len(args) != len(test.args) {
+			t.Errorf("%s: should have %d args; got %d\n", test.name, len(test.args), len(args))
+			continue
+		}
+
+		for i, arg := range args {
+			at, ok := arg.(time.Time)
+
+			if !ok || at.Location() != time.UTC || at.Format(DATE_TIME_LAYOUT) != test.args[i] {
+				t.Errorf("%s: should have utc %s; got %v\n", test.name, test.args[i], arg)
+			}
+		}
+	}
+
+	// ----------------------------------------------------------------------------------
+
+	invalid := []struct {
+		filter   Filter
+		timezone string
+		errMsg   string
+	}{
+		{Filter{Field: "createdAt", Operator: "on", Value: "foo"}, "", "invalid date value"},
+		{Filter{Field: "createdAt", Operator: "on", Value: 5}, "", "invalid date value"},
+		{Filter{Field: "createdAt", Operator: "on"}, "", "does not contain value"},
+		{Filter{Field: "createdAt", Operator: "today"}, "invalid", "invalid timezone"},
+		{Filter{Field: "name", Operator: "on", Value: "2024-03-10"}, "", "can not be filtered by date"},
+	}
+
+	for _, test := range invalid {
+		jsonBytes, _ := json.Marshal([]Filter{test.filter})
+
+		urlVals := url.Values{}
+		urlVals.Add(cfg.FilterParam, string(jsonBytes))
+		urlVals.Add(cfg.TimezoneParam, test.timezone)
+
+		req := httptest.NewRequest(http.MethodGet, "/url?"+urlVals.Encode(), nil)
+
+		if _, err := GetQueryBuilder(req, sq.Select("user.id").From("user"), dbFields, cfg); err == nil {
+			t.Errorf("%v: should have error\n", test.filter)
+		} else if !strings.Contains(err.Error(), test.errMsg) {
+			t.Errorf("%v: should have error '%s'; got '%s'\n", test.filter, test.errMsg, err.Error())
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -31,6 +32,43 @@ type DatabaseSetting struct {
 	SSLKey      string `yaml:"ssl_key" mapstructure:"ssl_key"`
 	SSLCert     string `yaml:"ssl_cert" mapstructure:"ssl_cert"`
 	SearchPath  string `yaml:"search_path" mapstructure:"search_path"`
+
+	// DriverName is name of registered sql driver to open connection with
+	//
+	// Default: DBType
+	DriverName string `yaml:"driver_name" mapstructure:"driver_name"`
+
+	// MaxOpenConns is max number of open connections to database
+	//
+	// Default: 0 (unlimited)
+	MaxOpenConns int `yaml:"max_open_conns" mapstructure:"max_open_conns"`
+
+	// MaxIdleConns is max number of idle connections kept in pool
+	//
+	// Default: 0 (database/sql default)
+	MaxIdleConns int `yaml:"max_idle_conns" mapstructure:"max_idle_conns"`
+
+	// ConnMaxLifetime is max amount of time a connection may be reused
+	//
+	// Default: 0 (reused forever)
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" mapstructure:"conn_max_lifetime"`
+
+	// ConnMaxIdleTime is max amount of time a connection may be idle
+	//
+	// Default: 0 (no limit)
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" mapstructure:"conn_max_idle_time"`
+
+	// PingAttempts is number of times to ping database on startup
+	// before giving up
+	//
+	// Default: DEFAULT_PING_ATTEMPTS
+	PingAttempts int `yaml:"ping_attempts" mapstructure:"ping_attempts"`
+
+	// PingBackoff is the initial wait between ping attempts which
+	// doubles after every failed attempt
+	//
+	// Default: DEFAULT_PING_BACKOFF
+	PingBackoff time.Duration `yaml:"ping_backoff" mapstructure:"ping_backoff"`
 }
 
 func (d DatabaseSetting) String() string {
@@ -50,6 +88,99 @@ func (d DatabaseSetting) String() string {
 	)
 }
 
+// DSN returns data source name in the format expected by the driver
+// of DBType, only including ssl parameters that are set
+//
+// Postgres returns url format, mysql returns go-sql-driver format
+// and sqlite returns DBName as the file path
+//
+// Mysql verifies ssl against system root CAs so returns error if
+// SSLRootCert, SSLCert or SSLKey are set
+//
+// Mysql also enables "multiStatements" so migrations with multiple
+// statements can be run, which means a query built from unescaped
+// user input could run extra statements so args should always be used
+func (d DatabaseSetting) DSN() (string, error) {
+	switch d.DBType {
+	case POSTGRES_DRIVER:
+		dbURL := url.URL{
+			Scheme: d.DBType,
+			User:   url.UserPassword(d.User, d.Password),
+			Host:   d.Host,
+			Path:   "/" + d.DBName,
+		}
+
+		if d.Port != 0 {
+			dbURL.Host = fmt.Sprintf("%s:%d", d.Host, d.Port)
+		}
+
+		query := url.Values{}
+		params := [][2]string{
+			{"sslmode", d.SSLMode},
+			{"sslrootcert", d.SSLRootCert},
+			{"sslkey", d.SSLKey},
+			{"sslcert", d.SSLCert},
+			{"search_path", d.SearchPath},
+		}
+
+		for _, param := range params {
+			if param[1] != "" {
+				query.Set(param[0], param[1])
+			}
+		}
+
+		dbURL.RawQuery = query.Encode()
+		return dbURL.String(), nil
+	case MYSQL_DRIVER:
+		// Custom certificates require registering a tls config with the
+		// mysql driver so they are rejected rather than silently ignored
+		if d.SSLRootCert != "" || d.SSLCert != "" || d.SSLKey != "" {
+			return "", fmt.Errorf("webutil: ssl certificate files are not supported for mysql dsn")
+		}
+
+		// Driver splits user from password on first ":" and dbname from
+		// params on "?" without unescaping either so they can't be escaped,
+		// where password can contain any character
+		if strings.Contains(d.User, ":") {
+			return "", fmt.Errorf("webutil: mysql user can't contain ':'")
+		}
+		if strings.ContainsAny(d.DBName, "/?") {
+			return "", fmt.Errorf("webutil: mysql db name can't contain '/' or '?'")
+		}
+
+		query := url.Values{}
+		query.Set("parseTime", "true")
+		query.Set("multiStatements", "true")
+
+		switch d.SSLMode {
+		case SSL_REQUIRE_MODE:
+			query.Set("tls", "skip-verify")
+		case SSL_VERIFY_CA_MODE, SSL_VERIFY_FULL_MODE:
+			// Verified against system root CAs
+			query.Set("tls", "true")
+		}
+
+		host := d.Host
+
+		if d.Port != 0 {
+			host = fmt.Sprintf("%s:%d", d.Host, d.Port)
+		}
+
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s)/%s?%s",
+			d.User,
+			d.Password,
+			host,
+			d.DBName,
+			query.Encode(),
+		), nil
+	case SQLITE_DRIVER:
+		return d.DBName, nil
+	default:
+		return "", fmt.Errorf("webutil: unsupported db type %q", d.DBType)
+	}
+}
+
 func (d *DatabaseSetting) ParseConnStr(connStr string) error {
 	dbURL, err := url.Parse(connStr)
 	if err != nil {
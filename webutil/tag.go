@@ -0,0 +1,360 @@
+package webutil
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//-------------------------- TYPES ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// TagRuleFunc builds validation rule for a "validate" struct tag option
+//
+// The fv parameter is the *FormValidation the struct is being validated
+// with, field is the struct field the tag is on and param is the value
+// after "=" within the option, e.g. "5" for "min=5"
+type TagRuleFunc func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error)
+
+//////////////////////////////////////////////////////////////////
+//------------------------ VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	tagRulesMu sync.RWMutex
+
+	// tagRules are the rules available to "validate" struct tags
+	tagRules = map[string]TagRuleFunc{
+		"required": func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+			return RequiredRule, nil
+		},
+		"email": regexTagRule(EmailRegex),
+		"zip":   regexTagRule(ZipRegex),
+		"phone": regexTagRule(PhoneNumberRegex),
+		"color": regexTagRule(ColorRegex),
+		"min":   thresholdTagRule(true),
+		"max":   thresholdTagRule(false),
+		"date": func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+			switch param {
+			case "past":
+				return fv.ValidateDate("", true, false, true), nil
+			case "future":
+				return fv.ValidateDate("", true, true, false), nil
+			default:
+				return nil, fmt.Errorf("webutil: date option must be 'past' or 'future'; got %q", param)
+			}
+		},
+		"exists": func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+			return fv.ValidateExists(0, param), nil
+		},
+		"unique": func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+			return fv.ValidateUniqueness(nil, 0, param), nil
+		},
+		"args": func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+			return fv.ValidateArgs(0, param), nil
+		},
+	}
+
+	// tagQueryRules are rules whose param is a query which may contain
+	// commas so they consume the rest of the tag and must come last
+	tagQueryRules = map[string]bool{
+		"exists": true,
+		"unique": true,
+		"args":   true,
+	}
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// RegisterTagRule registers rule under name so it can be used within
+// "validate" struct tags, overriding any rule with the same name
+func RegisterTagRule(name string, fn TagRuleFunc) {
+	tagRulesMu.Lock()
+	defer tagRulesMu.Unlock()
+
+	tagRules[name] = fn
+}
+
+// ValidateStruct validates form, which must be pointer to struct, based
+// on the "validate" struct tag of each field, e.g.
+//
+//	type UserForm struct {
+//		Email   string   `json:"email" validate:"required,email,unique=SELECT email FROM user WHERE email = ?"`
+//		Age     int      `json:"age" validate:"min=18"`
+//		Address Address  `json:"address" validate:"dive"`
+//		Phones  []Phone  `json:"phones" validate:"required,dive"`
+//	}
+//
+// Options are separated by commas, except for "exists", "unique" and "args"
+// which take a query that is passed to ValidateExists, ValidateUniqueness
+// and ValidateArgs with placeholder index 0 so they must be the last option
+//
+// The "dive" option validates the tags of a nested struct or each struct
+// within a slice.  Fields without a tag whose type implements
+// validation.Validatable are still validated
+//
+// Database rules are run with ctx through BatchValidateStruct and the
+// errors returned are the same validation.Errors as validation.ValidateStruct
+func (f *FormValidation) ValidateStruct(ctx context.Context, form any) error {
+	value := reflect.ValueOf(form)
+
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return validation.NewInternalError(validation.ErrStructPointer)
+	}
+
+	var buildErr error
+
+	err := f.WithContext(ctx).BatchValidateStruct(form, func(fv *FormValidation) []*validation.FieldRules {
+		var fields []*validation.FieldRules
+
+		if fields, buildErr = tagFieldRules(ctx, fv, value.Elem()); buildErr != nil {
+			return nil
+		}
+
+		return fields
+	})
+
+	if buildErr != nil {
+		return validation.NewInternalError(buildErr)
+	}
+
+	return err
+}
+
+// tagFieldRules builds field rules from the "validate" tag of each field of structVal
+func tagFieldRules(ctx context.Context, fv *FormValidation, structVal reflect.Value) ([]*validation.FieldRules, error) {
+	structType := structVal.Type()
+	fields := make([]*validation.FieldRules, 0, structType.NumField())
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPtr := structVal.Field(i).Addr().Interface()
+		tag, ok := field.Tag.Lookup(VALIDATE_TAG)
+
+		if tag == "-" {
+			continue
+		}
+		if !ok {
+			if _, ok = structVal.Field(i).Interface().(validation.Validatable); ok {
+				fields = append(fields, validation.Field(fieldPtr))
+			}
+
+			continue
+		}
+
+		rules, err := parseTagRules(ctx, fv, field, tag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "webutil: invalid validate tag for field %q", field.Name)
+		}
+
+		fields = append(fields, validation.Field(fieldPtr, rules...))
+	}
+
+	return fields, nil
+}
+
+func parseTagRules(ctx context.Context, fv *FormValidation, field reflect.StructField, tag string) ([]validation.Rule, error) {
+	rules := make([]validation.Rule, 0)
+
+	for tag != "" {
+		var option string
+
+		tag = strings.TrimLeft(tag, " \t")
+		name := tag
+		if idx := strings.IndexAny(tag, "=,"); idx != -1 {
+			name = tag[:idx]
+		}
+
+		rest := strings.TrimLeft(tag[len(name):], " \t")
+		name = strings.TrimSpace(name)
+
+		if tagQueryRules[name] && strings.HasPrefix(rest, "=") {
+			option, tag = tag, ""
+		} else if idx := strings.Index(tag, ","); idx != -1 {
+			option, tag = tag[:idx], tag[idx+1:]
+		} else {
+			option, tag = tag, ""
+		}
+
+		var param string
+
+		if idx := strings.Index(option, "="); idx != -1 {
+			param = strings.TrimSpace(option[idx+1:])
+		}
+
+		if name == "" {
+			continue
+		}
+
+		if name == "dive" {
+			rules = append(rules, &tagDiveRule{ctx: ctx, fv: fv})
+			continue
+		}
+
+		tagRulesMu.RLock()
+		fn, ok := tagRules[name]
+		tagRulesMu.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("webutil: unknown validate rule %q", name)
+		}
+
+		rule, err := fn(fv, field, param)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func regexTagRule(re *regexp.Regexp) TagRuleFunc {
+	return func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+		return &messageRule{rule: validation.Match(re), code: INVALID_FORMAT_CODE}, nil
+	}
+}
+
+// thresholdTagRule returns length rule for strings and slices else
+// min or max rule with param parsed to the kind of field
+func thresholdTagRule(isMin bool) TagRuleFunc {
+	return func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+		var threshold any
+		var err error
+
+		kind := deref(field.Type).Kind()
+
+		switch kind {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			length, err := strconv.Atoi(param)
+			if err != nil {
+				return nil, err
+			}
+
+			if isMin {
+				return &messageRule{
+					rule:   validation.Length(length, 0),
+					code:   MIN_LENGTH_CODE,
+					params: map[string]any{"min": length},
+				}, nil
+			}
+
+			return &messageRule{
+				rule:   validation.Length(0, length),
+				code:   MAX_LENGTH_CODE,
+				params: map[string]any{"max": length},
+			}, nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			threshold, err = strconv.ParseInt(param, INT_BASE, INT_BIT_SIZE)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			threshold, err = strconv.ParseUint(param, INT_BASE, INT_BIT_SIZE)
+		case reflect.Float32, reflect.Float64:
+			threshold, err = strconv.ParseFloat(param, 64)
+		default:
+			return nil, fmt.Errorf("webutil: min/max not supported for kind %q", kind)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if isMin {
+			return &messageRule{
+				rule:   validation.Min(threshold),
+				code:   MIN_VALUE_CODE,
+				params: map[string]any{"min": threshold},
+			}, nil
+		}
+
+		return &messageRule{
+			rule:   validation.Max(threshold),
+			code:   MAX_VALUE_CODE,
+			params: map[string]any{"max": threshold},
+		}, nil
+	}
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// tagDiveRule validates the tags of a nested struct or each
+// struct within a slice
+type tagDiveRule struct {
+	ctx context.Context
+	fv  *FormValidation
+}
+
+func (t *tagDiveRule) Validate(value any) error {
+	// Nested structs run their own batch so skip while the
+	// parent batch is only collecting queries
+	if isNilValue(value) || t.fv.batch.isCollecting() {
+		return nil
+	}
+
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		errs := validation.Errors{}
+
+		for i := 0; i < rv.Len(); i++ {
+			if err := t.validateStruct(rv.Index(i)); err != nil {
+				if internalErr, ok := err.(validation.InternalError); ok && internalErr.InternalError() != nil {
+					return err
+				}
+
+				errs[strconv.Itoa(i)] = err
+			}
+		}
+
+		if len(errs) > 0 {
+			return errs
+		}
+
+		return nil
+	default:
+		return t.validateStruct(rv)
+	}
+}
+
+func (t *tagDiveRule) validateStruct(rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return validation.NewInternalError(fmt.Errorf("webutil: dive can only be used on structs; got %q", rv.Kind()))
+	}
+
+	// Copy value so it can be addressed
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+
+	// Strip batch so nested struct runs its own batch
+	fv := new(FormValidation)
+	*fv = *t.fv
+	fv.batch = nil
+
+	return fv.ValidateStruct(t.ctx, ptr.Interface())
+}
@@ -0,0 +1,147 @@
+package webutil
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	validation "github.com/go-ozzo/ozzo-validation"
+	pkgerrors "github.com/pkg/errors"
+)
+
+type tagTestPhone struct {
+	Number string `json:"number" validate:"required,phone"`
+}
+
+type tagTestValidatable struct{}
+
+func (tagTestValidatable) Validate() error {
+	return pkgerrors.New("should not validate")
+}
+
+type tagTestForm struct {
+	Email   string         `json:"email" validate:"required,email,unique=SELECT email FROM user WHERE email = ?"`
+	Age     int            `json:"age" validate:"min=18,max=130"`
+	Name    string         `json:"name" validate:"required,max=5"`
+	RoleIDs []int          `json:"roleIDs" validate:"args=SELECT id, name FROM role WHERE id IN (?)"`
+	Phones  []tagTestPhone `json:"phones" validate:"required,dive"`
+	Ignored string         `json:"ignored"`
+}
+
+func TestValidateStructUnitTest(t *testing.T) {
+	var err error
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	mock.MatchExpectationsInOrder(false)
+
+	fv := NewFormValidation(db, FormValidationConfig{SQLBindVar: DOLLAR_SQL_BIND_VAR})
+
+	form := tagTestForm{
+		Email:   "foo@example.com",
+		Age:     10,
+		Name:    "too long",
+		RoleIDs: []int{1, 2},
+		Phones:  []tagTestPhone{{Number: "(555)-555-5555"}, {Number: "invalid"}},
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN EXISTS (SELECT email FROM user WHERE email = $1) THEN 1 ELSE 0 END")).
+		WithArgs(form.Email).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM (SELECT id, name FROM role WHERE id IN ($1, $2)) AS webutil_batch")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	err = fv.ValidateStruct(context.Background(), &form)
+
+	valErrs, ok := err.(validation.Errors)
+	if !ok {
+		t.Fatalf("should have validation.Errors; got %v\n", err)
+	}
+
+	errBytes, err := json.Marshal(valErrs)
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+
+	var actual map[string]any
+
+	if err = json.Unmarshal(errBytes, &actual); err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+
+	expected := map[string]any{
+		"email":  ALREADY_EXISTS_TXT,
+		"age":    "Can't be less than 18",
+		"name":   "length can't be greater than 5",
+		"phones": map[string]any{"1": map[string]any{"number": INVALID_FORMAT_TXT}},
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("errors should be %v; got %v\n", expected, actual)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	type invalidForm struct {
+		Name string `validate:"invalid"`
+	}
+
+	if err = fv.ValidateStruct(context.Background(), &invalidForm{}); err == nil {
+		t.Errorf("should have error\n")
+	} else if _, ok = pkgerrors.Cause(err).(validation.InternalError); !ok {
+		t.Errorf("should have validation.InternalError; got %T\n", err)
+	}
+
+	// ---------------------------------------------------------------------
+
+	RegisterTagRule("even", func(fv *FormValidation, field reflect.StructField, param string) (validation.Rule, error) {
+		return validation.By(func(value any) error {
+			if value.(int)%2 != 0 {
+				return pkgerrors.New("must be even")
+			}
+
+			return nil
+		}), nil
+	})
+
+	type customForm struct {
+		Num int `json:"num" validate:"even"`
+	}
+
+	if err = fv.ValidateStruct(context.Background(), &customForm{Num: 3}); err == nil {
+		t.Errorf("should have error\n")
+	} else if err.Error() != "num: must be even." {
+		t.Errorf("should have even error; got %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	type spacedForm struct {
+		RoleIDs []int              `json:"roleIDs" validate:"required, args=SELECT id FROM role WHERE code IN ('a', 'b') AND id IN (?)"`
+		Skipped tagTestValidatable `json:"skipped" validate:"-"`
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM (SELECT id FROM role WHERE code IN ('a', 'b') AND id IN ($1, $2)) AS webutil_batch")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	if err = fv.ValidateStruct(context.Background(), &spacedForm{RoleIDs: []int{1, 2}}); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
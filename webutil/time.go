@@ -1,100 +1,277 @@
 package webutil
 
 import (
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
+//////////////////////////////////////////////////////////////////
+//-------------------------- TYPES ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// DSTGapPolicy determines how a wall clock time that does not exist,
+// due to clocks moving forward, is resolved
+type DSTGapPolicy int
+
+// DSTOverlapPolicy determines how a wall clock time that occurs twice,
+// due to clocks moving back, is resolved
+type DSTOverlapPolicy int
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// DSTPolicy is used to resolve wall clock times that fall within
+// daylight saving time transitions
+type DSTPolicy struct {
+	// Gap determines how nonexistent times are resolved
+	//
+	// Default: DST_GAP_SHIFT
+	Gap DSTGapPolicy
+
+	// Overlap determines how ambiguous times are resolved
+	//
+	// Default: DST_OVERLAP_EARLIER
+	Overlap DSTOverlapPolicy
+}
+
+// TimeRange is the half open range [Start, End)
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- VARIABLES --------------------------
+//////////////////////////////////////////////////////////////////
+
+var (
+	// locationCache caches *time.Location by name as
+	// time.LoadLocation reads tzdata on every call
+	locationCache sync.Map
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// LoadLocation is the same as time.LoadLocation except locations
+// are cached after being loaded the first time
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
 // ConvertToTimezone takes in date string along with timezone and returns
 // the same time clock but with given timezone, meaning that the time
 // actually changes since we keep the clock the same
+//
+// Wall clock times within daylight saving time transitions are resolved
+// with the default DSTPolicy
 func ConvertToTimezone(value time.Time, timezone string, includeTime bool) (time.Time, error) {
-	loc, err := time.LoadLocation(timezone)
+	loc, err := LoadLocation(timezone)
 	if err != nil {
-		return time.Time{}, errors.Wrap(err, "")
+		return time.Time{}, err
 	}
 
-	var newTime time.Time
-
-	if includeTime {
-		newTime = time.Date(
-			value.Year(),
-			value.Month(),
-			value.Day(),
-			value.Hour(),
-			value.Minute(),
-			value.Second(),
-			value.Nanosecond(),
-			loc,
-		)
-	} else {
-		newTime = time.Date(
-			value.Year(),
-			value.Month(),
-			value.Day(),
-			0,
-			0,
-			0,
-			0,
-			loc,
-		)
+	if !includeTime {
+		value = time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, value.Location())
 	}
 
-	return newTime, nil
-}
-
-// // GetCurrentLocalDateTimeInUTC will return the local date and time based on
-// // the time zone passed
-// func GetCurrentLocalDateTimeInUTC(timezone string) (time.Time, error) {
-// 	return getUTC(timezone, true)
-// }
-
-// // GetCurrentLocalDateInUTC will return the local date based on
-// // the time zone passed
-// func GetCurrentLocalDateInUTC(timezone string) (time.Time, error) {
-// 	return getUTC(timezone, false)
-// }
-
-// func getUTC(timezone string, includeTime bool) (time.Time, error) {
-// 	var utcTime time.Time
-// 	location, err := time.LoadLocation(timezone)
-
-// 	if err != nil {
-// 		return time.Time{}, errors.Wrap(err, "")
-// 	}
-
-// 	utc, err := time.LoadLocation("UTC")
-
-// 	if err != nil {
-// 		return time.Time{}, errors.Wrap(err, "")
-// 	}
-
-// 	localTime := time.Now().In(location)
-
-// 	if includeTime {
-// 		utcTime = time.Date(
-// 			localTime.Year(),
-// 			localTime.Month(),
-// 			localTime.Day(),
-// 			localTime.Hour(),
-// 			localTime.Minute(),
-// 			localTime.Second(),
-// 			localTime.Nanosecond(),
-// 			utc,
-// 		)
-// 	} else {
-// 		utcTime = time.Date(
-// 			localTime.Year(),
-// 			localTime.Month(),
-// 			localTime.Day(),
-// 			0,
-// 			0,
-// 			0,
-// 			0,
-// 			utc,
-// 		)
-// 	}
-
-// 	return utcTime, nil
-// }
+	return ResolveWallClock(value, loc, DSTPolicy{})
+}
+
+// SameWallClock returns time within timezone that has the same wall
+// clock as value, e.g. 09:00 in UTC becomes 09:00 in "America/New_York"
+//
+// Returns ErrNonexistentTime or ErrAmbiguousTime if policy is set to error
+func SameWallClock(value time.Time, timezone string, policy DSTPolicy) (time.Time, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return ResolveWallClock(value, loc, policy)
+}
+
+// ResolveWallClock returns time within loc with the wall clock of value,
+// ignoring the location of value, using policy for times that fall within
+// daylight saving time gaps and overlaps
+func ResolveWallClock(value time.Time, loc *time.Location, policy DSTPolicy) (time.Time, error) {
+	wall := time.Date(
+		value.Year(),
+		value.Month(),
+		value.Day(),
+		value.Hour(),
+		value.Minute(),
+		value.Second(),
+		value.Nanosecond(),
+		time.UTC,
+	)
+
+	// Transitions happen at most once within a day so the offsets a day
+	// before and after are the only offsets the wall clock can have
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	candidates := make([]time.Time, 0, 2)
+
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+
+		if sameWall(t, wall) && (len(candidates) == 0 || !candidates[0].Equal(t)) {
+			candidates = append(candidates, t)
+		}
+	}
+
+	switch len(candidates) {
+	case 1:
+		return candidates[0], nil
+	case 2:
+		earlier, later := candidates[0], candidates[1]
+		if later.Before(earlier) {
+			earlier, later = later, earlier
+		}
+
+		switch policy.Overlap {
+		case DST_OVERLAP_LATER:
+			return later, nil
+		case DST_OVERLAP_ERROR:
+			return time.Time{}, errors.Wrapf(ErrAmbiguousTime, "%s in %s", wall.Format(DATE_TIME_LAYOUT), loc)
+		default:
+			return earlier, nil
+		}
+	}
+
+	// Wall clock was skipped so applying the offset from before the
+	// transition lands after the transition by the length of the gap
+	shifted := wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+
+	switch policy.Gap {
+	case DST_GAP_TRANSITION:
+		start, _ := shifted.ZoneBounds()
+		return start, nil
+	case DST_GAP_ERROR:
+		return time.Time{}, errors.Wrapf(ErrNonexistentTime, "%s in %s", wall.Format(DATE_TIME_LAYOUT), loc)
+	default:
+		return shifted, nil
+	}
+}
+
+// CurrentLocalDateTime returns the current date and time within timezone
+func CurrentLocalDateTime(timezone string) (time.Time, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Now().In(loc), nil
+}
+
+// CurrentLocalDate returns the start of the current date within timezone
+func CurrentLocalDate(timezone string) (time.Time, error) {
+	now, err := CurrentLocalDateTime(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return startOfDay(now.Year(), now.Month(), now.Day(), now.Location()), nil
+}
+
+// DayRange returns the day of value within timezone as UTC range
+//
+// Days are not always 24 hours as daylight saving time transitions
+// make days either shorter or longer
+func DayRange(value time.Time, timezone string) (TimeRange, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return TimeRange{}, err
+	}
+
+	t := value.In(loc)
+	return localRange(t.Year(), t.Month(), t.Day(), 0, 0, 1, loc), nil
+}
+
+// WeekRange returns the week of value within timezone as UTC range
+// where weeks begin on weekStart
+func WeekRange(value time.Time, timezone string, weekStart time.Weekday) (TimeRange, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return TimeRange{}, err
+	}
+
+	t := value.In(loc)
+	daysSinceStart := (int(t.Weekday()) - int(weekStart) + 7) % 7
+
+	return localRange(t.Year(), t.Month(), t.Day()-daysSinceStart, 0, 0, 7, loc), nil
+}
+
+// MonthRange returns the month of value within timezone as UTC range
+func MonthRange(value time.Time, timezone string) (TimeRange, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return TimeRange{}, err
+	}
+
+	t := value.In(loc)
+	return localRange(t.Year(), t.Month(), 1, 0, 1, 0, loc), nil
+}
+
+// localRange returns UTC range from the start of the given date within
+// loc to the start of the date after adding years, months and days
+func localRange(year int, month time.Month, day, years, months, days int, loc *time.Location) TimeRange {
+	start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(years, months, days)
+
+	return TimeRange{
+		Start: startOfDay(start.Year(), start.Month(), start.Day(), loc).UTC(),
+		End:   startOfDay(end.Year(), end.Month(), end.Day(), loc).UTC(),
+	}
+}
+
+// startOfDay returns first instant of date within loc, which is
+// the transition if midnight is skipped by daylight saving time
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	t, _ := ResolveWallClock(
+		time.Date(year, month, day, 0, 0, 0, 0, time.UTC),
+		loc,
+		DSTPolicy{Gap: DST_GAP_TRANSITION, Overlap: DST_OVERLAP_EARLIER},
+	)
+
+	return t
+}
+
+// sameWall determines if t has the same wall clock as wall
+func sameWall(t, wall time.Time) bool {
+	return t.Year() == wall.Year() &&
+		t.YearDay() == wall.YearDay() &&
+		t.Hour() == wall.Hour() &&
+		t.Minute() == wall.Minute() &&
+		t.Second() == wall.Second() &&
+		t.Nanosecond() == wall.Nanosecond()
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Contains determines if t is within [Start, End)
+func (t TimeRange) Contains(value time.Time) bool {
+	return !value.Before(t.Start) && value.Before(t.End)
+}
+
+// Duration returns length of range
+func (t TimeRange) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
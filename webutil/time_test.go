@@ -0,0 +1,214 @@
+package webutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadLocationUnitTest(t *testing.T) {
+	first, err := LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	second, _ := LoadLocation("America/New_York")
+
+	if first != second {
+		t.Errorf("should return cached location\n")
+	}
+	if _, err = LoadLocation("invalid"); err == nil {
+		t.Errorf("should have error\n")
+	}
+}
+
+func TestResolveWallClockUnitTest(t *testing.T) {
+	loc, _ := LoadLocation("America/New_York")
+
+	tests := []struct {
+		name     string
+		value    time.Time
+		policy   DSTPolicy
+		expected string
+		err      error
+	}{
+		{
+			"normal",
+			time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+			DSTPolicy{},
+			"2024-03-15 13:00:00",
+			nil,
+		},
+		{
+			"gap shift",
+			time.Date(2024, 3, 10, 2, 30, 0, 0, time.UTC),
+			DSTPolicy{},
+			"2024-03-10 07:30:00",
+			nil,
+		},
+		{
+			"gap transition",
+			time.Date(2024, 3, 10, 2, 30, 0, 0, time.UTC),
+			DSTPolicy{Gap: DST_GAP_TRANSITION},
+			"2024-03-10 07:00:00",
+			nil,
+		},
+		{
+			"gap error",
+			time.Date(2024, 3, 10, 2, 30, 0, 0, time.UTC),
+			DSTPolicy{Gap: DST_GAP_ERROR},
+			"",
+			ErrNonexistentTime,
+		},
+		{
+			"overlap earlier",
+			time.Date(2024, 11, 3, 1, 30, 0, 0, time.UTC),
+			DSTPolicy{},
+			"2024-11-03 05:30:00",
+			nil,
+		},
+		{
+			"overlap later",
+			time.Date(2024, 11, 3, 1, 30, 0, 0, time.UTC),
+			DSTPolicy{Overlap: DST_OVERLAP_LATER},
+			"2024-11-03 06:30:00",
+			nil,
+		},
+		{
+			"overlap error",
+			time.Date(2024, 11, 3, 1, 30, 0, 0, time.UTC),
+			DSTPolicy{Overlap: DST_OVERLAP_ERROR},
+			"",
+			ErrAmbiguousTime,
+		},
+	}
+
+	for _, test := range tests {
+		res, err := ResolveWallClock(test.value, loc, test.policy)
+
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: should have error %v; got %v\n", test.name, test.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+		} else if res.UTC().Format(DATE_TIME_LAYOUT) != test.expected {
+			t.Errorf("%s: should have %s; got %s\n", test.name, test.expected, res.UTC().Format(DATE_TIME_LAYOUT))
+		} else if res.Location() != loc {
+			t.Errorf("%s: should be within location\n", test.name)
+		}
+	}
+
+	// ---------------------------------------------------------------
+
+	res, err := SameWallClock(time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC), "Asia/Tokyo", DSTPolicy{})
+	if err != nil || res.Hour() != 9 || res.UTC().Hour() != 0 {
+		t.Errorf("should have 09:00 tokyo; got %s %v\n", res.String(), err)
+	}
+
+	res, err = ConvertToTimezone(time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC), "America/New_York", false)
+	if err != nil || res.UTC().Format(DATE_TIME_LAYOUT) != "2024-03-15 04:00:00" {
+		t.Errorf("should have midnight new york; got %s %v\n", res.String(), err)
+	}
+}
+
+func TestTimeRangeUnitTest(t *testing.T) {
+	value := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rangeFn  func() (TimeRange, error)
+		start    string
+		end      string
+		duration time.Duration
+	}{
+		{
+			"dst day",
+			func() (TimeRange, error) { return DayRange(value, "America/New_York") },
+			"2024-03-10 05:00:00",
+			"2024-03-11 04:00:00",
+			23 * time.Hour,
+		},
+		{
+			"week monday",
+			func() (TimeRange, error) { return WeekRange(value, "America/New_York", time.Monday) },
+			"2024-03-04 05:00:00",
+			"2024-03-11 04:00:00",
+			7*24*time.Hour - time.Hour,
+		},
+		{
+			"week sunday",
+			func() (TimeRange, error) { return WeekRange(value, "America/New_York", time.Sunday) },
+			"2024-03-10 05:00:00",
+			"2024-03-17 04:00:00",
+			7*24*time.Hour - time.Hour,
+		},
+		{
+			"month",
+			func() (TimeRange, error) { return MonthRange(value, "UTC") },
+			"2024-03-01 00:00:00",
+			"2024-04-01 00:00:00",
+			31 * 24 * time.Hour,
+		},
+		{
+			// Midnight is skipped in Santiago so day starts at 01:00
+			"midnight gap",
+			func() (TimeRange, error) {
+				return DayRange(time.Date(2024, 9, 8, 12, 0, 0, 0, time.UTC), "America/Santiago")
+			},
+			"2024-09-08 04:00:00",
+			"2024-09-09 03:00:00",
+			23 * time.Hour,
+		},
+	}
+
+	for _, test := range tests {
+		r, err := test.rangeFn()
+		if err != nil {
+			t.Errorf("%s: should not have error; got %s\n", test.name, err.Error())
+			continue
+		}
+
+		if r.Start.Format(DATE_TIME_LAYOUT) != test.start || r.End.Format(DATE_TIME_LAYOUT) != test.end {
+			t.Errorf(
+				"%s: should have [%s, %s); got [%s, %s)\n",
+				test.name,
+				test.start,
+				test.end,
+				r.Start.Format(DATE_TIME_LAYOUT),
+				r.End.Format(DATE_TIME_LAYOUT),
+			)
+		}
+		if r.Duration() != test.duration {
+			t.Errorf("%s: should have duration %s; got %s\n", test.name, test.duration, r.Duration())
+		}
+		if r.Start.Location() != time.UTC {
+			t.Errorf("%s: should be utc\n", test.name)
+		}
+		if !r.Contains(r.Start) || r.Contains(r.End) {
+			t.Errorf("%s: should contain start but not end\n", test.name)
+		}
+	}
+
+	if _, err := DayRange(value, "invalid"); err == nil {
+		t.Errorf("should have error\n")
+	}
+
+	// ---------------------------------------------------------------
+
+	today, err := CurrentLocalDate("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if today.Hour() != 0 || today.Location().String() != "Asia/Tokyo" {
+		t.Errorf("should have midnight tokyo; got %s\n", today.String())
+	}
+
+	now, _ := CurrentLocalDateTime("Asia/Tokyo")
+	if now.Sub(today) < 0 || now.Sub(today) >= 24*time.Hour {
+		t.Errorf("should be within today; got %s\n", now.String())
+	}
+}
@@ -0,0 +1,364 @@
+package webutil
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////
+//------------------------ INTERFACES --------------------------
+//////////////////////////////////////////////////////////////////
+
+// TokenNonceStore records nonces of consumed action tokens
+type TokenNonceStore interface {
+	// Consume records nonce as used until expiresAt and should
+	// return ErrTokenUsed if nonce was already recorded
+	Consume(ctx context.Context, nonce string, expiresAt time.Time) error
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- STRUCTS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// TokensConfig is config struct used in the initialization of *Tokens
+type TokensConfig struct {
+	// TTL is how long created tokens are valid for
+	//
+	// Default: 24 hours
+	TTL time.Duration
+
+	// NonceStore records consumed nonces so tokens can only be used once
+	//
+	// Default: *MemoryTokenNonceStore
+	NonceStore TokenNonceStore
+}
+
+// ActionToken is decoded payload of token created by Tokens
+type ActionToken struct {
+	// Purpose is action token was created for, ex. "reset-password"
+	Purpose string
+
+	// Subject is who or what token was created for, ex. a user id
+	Subject string
+
+	// ExpiresAt is time token is no longer valid
+	ExpiresAt time.Time
+
+	// Nonce is random value that makes token single use
+	Nonce string
+}
+
+// Tokens creates and verifies signed, expiring action tokens used
+// for links such as email verification and password reset
+type Tokens struct {
+	codec  *securecookie.SecureCookie
+	config TokensConfig
+}
+
+// MemoryTokenNonceStore implements TokenNonceStore in memory
+// so consumed nonces are only tracked per process
+type MemoryTokenNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// TokenNonceStoreConfig is config struct used in the initialization
+// of *DBTokenNonceStore
+type TokenNonceStoreConfig struct {
+	// DBType should be one of POSTGRES_DRIVER, MYSQL_DRIVER or SQLITE_DRIVER
+	DBType string
+
+	// TableName is table consumed nonces are stored in
+	//
+	// Default: DEFAULT_TOKEN_NONCE_TABLE
+	TableName string
+}
+
+// DBTokenNonceStore implements TokenNonceStore by storing consumed
+// nonces in a database so tokens are single use across processes
+type DBTokenNonceStore struct {
+	db     Database
+	config TokenNonceStoreConfig
+}
+
+// tokenPayload is json encoded within token
+type tokenPayload struct {
+	Purpose   string `json:"p"`
+	Subject   string `json:"s"`
+	ExpiresAt int64  `json:"e"`
+	Nonce     string `json:"n"`
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- FUNCTIONS --------------------------
+//////////////////////////////////////////////////////////////////
+
+// NewTokens returns *Tokens which signs tokens with AuthKey of auth
+// and encrypts them with EncryptKey of auth if set
+//
+// Will return error if keys of auth are invalid
+func NewTokens(auth SessionAuth, config TokensConfig) (*Tokens, error) {
+	var encryptKey []byte
+
+	if auth.AuthKey == "" {
+		return nil, errors.WithStack(fmt.Errorf("webutil: auth key is required for tokens"))
+	}
+	if auth.EncryptKey != "" {
+		encryptKey = []byte(auth.EncryptKey)
+	}
+
+	if config.TTL == 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.NonceStore == nil {
+		config.NonceStore = NewMemoryTokenNonceStore()
+	}
+
+	t := &Tokens{
+		codec: securecookie.New([]byte(auth.AuthKey), encryptKey).
+			SetSerializer(securecookie.JSONEncoder{}).
+			MaxAge(0),
+		config: config,
+	}
+
+	// Encode once so invalid keys are caught on initialization
+	if _, err := t.codec.Encode(TOKEN_COOKIE_NAME, tokenPayload{}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return t, nil
+}
+
+// NewMemoryTokenNonceStore returns *MemoryTokenNonceStore
+func NewMemoryTokenNonceStore() *MemoryTokenNonceStore {
+	return &MemoryTokenNonceStore{
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// NewDBTokenNonceStore returns *DBTokenNonceStore
+//
+// Will return error if DBType is not supported
+func NewDBTokenNonceStore(db Database, config TokenNonceStoreConfig) (*DBTokenNonceStore, error) {
+	switch config.DBType {
+	case POSTGRES_DRIVER, MYSQL_DRIVER, SQLITE_DRIVER:
+	default:
+		return nil, errors.WithStack(fmt.Errorf("webutil: unsupported token nonce store db type %q", config.DBType))
+	}
+
+	if config.TableName == "" {
+		config.TableName = DEFAULT_TOKEN_NONCE_TABLE
+	}
+
+	return &DBTokenNonceStore{
+		db:     db,
+		config: config,
+	}, nil
+}
+
+//////////////////////////////////////////////////////////////////
+//------------------------- METHODS ----------------------------
+//////////////////////////////////////////////////////////////////
+
+// Create returns url safe token for purpose and subject that
+// expires after configured TTL
+func (t *Tokens) Create(purpose, subject string) (string, error) {
+	return t.CreateWithTTL(purpose, subject, t.config.TTL)
+}
+
+// CreateWithTTL returns url safe token for purpose and subject
+// that expires after ttl
+func (t *Tokens) CreateWithTTL(purpose, subject string, ttl time.Duration) (string, error) {
+	nonce := make([]byte, 16)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	encoded, err := t.codec.Encode(TOKEN_COOKIE_NAME, tokenPayload{
+		Purpose:   purpose,
+		Subject:   subject,
+		ExpiresAt: timeNow().Add(ttl).Unix(),
+		Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	// Padding is removed so token doesn't need to be escaped in urls
+	return strings.TrimRight(encoded, "="), nil
+}
+
+// Verify returns decoded token if token has a valid signature, was
+// created for purpose and has not expired
+//
+// Verify does not consume token so it can be used to check a token
+// before showing a form, where Consume is then used on submission
+func (t *Tokens) Verify(purpose, token string) (ActionToken, error) {
+	var payload tokenPayload
+
+	if pad := len(token) % 4; pad != 0 {
+		token += strings.Repeat("=", 4-pad)
+	}
+
+	// Signature is checked in constant time by securecookie
+	if err := t.codec.Decode(TOKEN_COOKIE_NAME, token, &payload); err != nil {
+		return ActionToken{}, ErrInvalidToken
+	}
+	if subtle.ConstantTimeCompare([]byte(payload.Purpose), []byte(purpose)) != 1 {
+		return ActionToken{}, ErrInvalidToken
+	}
+
+	actionToken := ActionToken{
+		Purpose:   payload.Purpose,
+		Subject:   payload.Subject,
+		ExpiresAt: time.Unix(payload.ExpiresAt, 0).UTC(),
+		Nonce:     payload.Nonce,
+	}
+
+	if !timeNow().Before(actionToken.ExpiresAt) {
+		return actionToken, ErrTokenExpired
+	}
+
+	return actionToken, nil
+}
+
+// Consume verifies token and records its nonce so it can't be used again
+//
+// Will return ErrTokenUsed if token was already consumed
+func (t *Tokens) Consume(ctx context.Context, purpose, token string) (ActionToken, error) {
+	actionToken, err := t.Verify(purpose, token)
+	if err != nil {
+		return actionToken, err
+	}
+
+	if err = t.config.NonceStore.Consume(ctx, actionToken.Nonce, actionToken.ExpiresAt); err != nil {
+		return actionToken, err
+	}
+
+	return actionToken, nil
+}
+
+// Consume records nonce, removing expired nonces along the way
+func (m *MemoryTokenNonceStore) Consume(ctx context.Context, nonce string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(timeNow())
+
+	if _, ok := m.nonces[nonce]; ok {
+		return ErrTokenUsed
+	}
+
+	m.nonces[nonce] = expiresAt
+	return nil
+}
+
+// sweep removes expired nonces at most once a minute so
+// nonces of expired tokens don't leak memory
+//
+// Nonces left until the next sweep can't be reused as
+// their tokens have expired
+func (m *MemoryTokenNonceStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+
+	m.lastSweep = now
+
+	for key, exp := range m.nonces {
+		if !now.Before(exp) {
+			delete(m.nonces, key)
+		}
+	}
+}
+
+// CreateTable creates table consumed nonces are stored in if it doesn't exist
+func (d *DBTokenNonceStore) CreateTable(ctx context.Context) error {
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s ("+
+			"nonce VARCHAR(64) NOT NULL PRIMARY KEY, "+
+			"expires_at TIMESTAMP NOT NULL, "+
+			"created_at TIMESTAMP NOT NULL)",
+		d.config.TableName,
+	)
+
+	if _, err := d.db.ExecContext(ctx, query); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+// Consume inserts nonce and returns ErrTokenUsed if nonce already exists
+func (d *DBTokenNonceStore) Consume(ctx context.Context, nonce string, expiresAt time.Time) error {
+	_, err := d.db.ExecContext(
+		ctx,
+		Rebind(d.bindVar(), fmt.Sprintf(
+			"INSERT INTO %s (nonce, expires_at, created_at) VALUES (?, ?, ?)",
+			d.config.TableName,
+		)),
+		nonce,
+		expiresAt.UTC(),
+		timeNow().UTC(),
+	)
+	if err == nil {
+		return nil
+	}
+
+	// Unique violations differ between drivers so check whether
+	// nonce exists to tell them apart from other errors
+	rows, queryErr := d.db.QueryContext(
+		ctx,
+		Rebind(d.bindVar(), fmt.Sprintf("SELECT nonce FROM %s WHERE nonce = ?", d.config.TableName)),
+		nonce,
+	)
+	if queryErr != nil {
+		return errors.Wrapf(err, "webutil: could not check nonce after insert failed: %s", queryErr)
+	}
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return ErrTokenUsed
+	}
+
+	if queryErr = rows.Err(); queryErr != nil {
+		return errors.Wrapf(err, "webutil: could not check nonce after insert failed: %s", queryErr)
+	}
+
+	return errors.WithStack(err)
+}
+
+// DeleteExpired removes nonces of expired tokens as they can no
+// longer be verified and returns number of nonces removed
+func (d *DBTokenNonceStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := d.db.ExecContext(
+		ctx,
+		Rebind(d.bindVar(), fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?", d.config.TableName)),
+		timeNow().UTC(),
+	)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return res.RowsAffected()
+}
+
+func (d *DBTokenNonceStore) bindVar() int {
+	if d.config.DBType == POSTGRES_DRIVER {
+		return DOLLAR_SQL_BIND_VAR
+	}
+
+	return QUESTION_SQL_BIND_VAR
+}
@@ -0,0 +1,207 @@
+package webutil
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTokensUnitTest(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := NewTokens(SessionAuth{}, TokensConfig{}); err == nil {
+		t.Errorf("should have error without auth key\n")
+	}
+	if _, err := NewTokens(SessionAuth{AuthKey: "auth", EncryptKey: "short"}, TokensConfig{}); err == nil {
+		t.Errorf("should have error for invalid encrypt key\n")
+	}
+
+	tokens, err := NewTokens(SessionAuth{
+		AuthKey:    "b5e7d1c3a0f94e2a8c6d4b2a0e8f6c4d",
+		EncryptKey: "0123456789abcdef0123456789abcdef",
+	}, TokensConfig{TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	token, err := tokens.Create("reset-password", "user-1")
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if strings.ContainsAny(token, "=+/") {
+		t.Errorf("should be url safe; got %s\n", token)
+	}
+
+	actionToken, err := tokens.Verify("reset-password", token)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if actionToken.Subject != "user-1" || !actionToken.ExpiresAt.Equal(now.Add(time.Hour)) || actionToken.Nonce == "" {
+		t.Errorf("should have decoded token; got %+v\n", actionToken)
+	}
+
+	if _, err = tokens.Verify("verify-email", token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("should have ErrInvalidToken for other purpose; got %v\n", err)
+	}
+	if _, err = tokens.Verify("reset-password", token[:len(token)-2]+"xx"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("should have ErrInvalidToken for tampered token; got %v\n", err)
+	}
+
+	other, _ := NewTokens(SessionAuth{AuthKey: "other"}, TokensConfig{})
+
+	if _, err = other.Verify("reset-password", token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("should have ErrInvalidToken for other keys; got %v\n", err)
+	}
+
+	// ---------------------------------------------------------------
+
+	if _, err = tokens.Consume(ctx, "reset-password", token); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if _, err = tokens.Consume(ctx, "reset-password", token); !errors.Is(err, ErrTokenUsed) {
+		t.Errorf("should have ErrTokenUsed; got %v\n", err)
+	}
+
+	second, _ := tokens.Create("reset-password", "user-1")
+
+	if _, err = tokens.Consume(ctx, "reset-password", second); err != nil {
+		t.Errorf("should allow new token for same subject; got %v\n", err)
+	}
+
+	now = now.Add(time.Hour)
+
+	if _, err = tokens.Verify("reset-password", token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("should have ErrTokenExpired; got %v\n", err)
+	}
+
+	if token, _ = tokens.CreateWithTTL("reset-password", "user-1", time.Minute); token == "" {
+		t.Fatalf("should have token\n")
+	}
+	if _, err = tokens.Verify("reset-password", token); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+}
+
+func TestDBTokenNonceStoreUnitTest(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	ctx := context.Background()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	if _, err = NewDBTokenNonceStore(db, TokenNonceStoreConfig{DBType: "invalid"}); err == nil {
+		t.Errorf("should have error for invalid db type\n")
+	}
+
+	store, err := NewDBTokenNonceStore(db, TokenNonceStoreConfig{DBType: POSTGRES_DRIVER})
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	tokens, _ := NewTokens(SessionAuth{AuthKey: "auth"}, TokensConfig{NonceStore: store})
+	token, _ := tokens.Create("verify-email", "user-1")
+	actionToken, _ := tokens.Verify("verify-email", token)
+
+	insertQuery := regexp.QuoteMeta("INSERT INTO token_nonces (nonce, expires_at, created_at) VALUES ($1, $2, $3)")
+	selectQuery := regexp.QuoteMeta("SELECT nonce FROM token_nonces WHERE nonce = $1")
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS token_nonces")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insertQuery).
+		WithArgs(actionToken.Nonce, now.Add(24*time.Hour), now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertQuery).
+		WillReturnError(errors.New("duplicate key"))
+	mock.ExpectQuery(selectQuery).
+		WithArgs(actionToken.Nonce).
+		WillReturnRows(sqlmock.NewRows([]string{"nonce"}).AddRow(actionToken.Nonce))
+	mock.ExpectExec(insertQuery).
+		WillReturnError(errors.New("connection lost"))
+	mock.ExpectQuery(selectQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"nonce"}))
+	mock.ExpectExec(insertQuery).
+		WillReturnError(errors.New("connection lost"))
+	mock.ExpectQuery(selectQuery).
+		WillReturnError(errors.New("select failed"))
+	mock.ExpectExec(insertQuery).
+		WillReturnError(errors.New("connection lost"))
+	mock.ExpectQuery(selectQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"nonce"}).RowError(0, errors.New("row failed")).AddRow(actionToken.Nonce))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM token_nonces WHERE expires_at <= $1")).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	if err = store.CreateTable(ctx); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if _, err = tokens.Consume(ctx, "verify-email", token); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if _, err = tokens.Consume(ctx, "verify-email", token); !errors.Is(err, ErrTokenUsed) {
+		t.Errorf("should have ErrTokenUsed; got %v\n", err)
+	}
+	if _, err = tokens.Consume(ctx, "verify-email", token); err == nil || errors.Is(err, ErrTokenUsed) {
+		t.Errorf("should have insert error; got %v\n", err)
+	}
+	if _, err = tokens.Consume(ctx, "verify-email", token); err == nil || !strings.Contains(err.Error(), "select failed") || !strings.Contains(err.Error(), "connection lost") {
+		t.Errorf("should have insert and select errors; got %v\n", err)
+	}
+	if _, err = tokens.Consume(ctx, "verify-email", token); err == nil || !strings.Contains(err.Error(), "row failed") || !strings.Contains(err.Error(), "connection lost") {
+		t.Errorf("should have insert and rows errors; got %v\n", err)
+	}
+
+	deleted, err := store.DeleteExpired(ctx)
+	if err != nil || deleted != 3 {
+		t.Errorf("should delete 3 nonces; got %d, %v\n", deleted, err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("should meet expectations; got %s\n", err.Error())
+	}
+}
+
+func TestMemoryTokenNonceStoreUnitTest(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	ctx := context.Background()
+	store := NewMemoryTokenNonceStore()
+
+	if err := store.Consume(ctx, "a", now.Add(30*time.Second)); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	now = now.Add(45 * time.Second)
+
+	if err := store.Consume(ctx, "b", now.Add(time.Hour)); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+	if len(store.nonces) != 2 {
+		t.Errorf("should not sweep within a minute of last sweep; got %d nonces\n", len(store.nonces))
+	}
+
+	now = now.Add(time.Minute)
+
+	if err := store.Consume(ctx, "b", now.Add(time.Hour)); !errors.Is(err, ErrTokenUsed) {
+		t.Errorf("should have ErrTokenUsed; got %v\n", err)
+	}
+	if _, ok := store.nonces["a"]; ok || len(store.nonces) != 1 {
+		t.Errorf("should sweep expired nonces; got %d nonces\n", len(store.nonces))
+	}
+}
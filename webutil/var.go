@@ -18,6 +18,46 @@ var (
 
 	// ErrInvalidJSON is used when there is an error unmarshalling a struct
 	ErrInvalidJSON = errors.New("webutil: " + invalidJSONTxt)
+
+	// ErrBodyTooLarge is used when request body is larger than allowed
+	ErrBodyTooLarge = errors.New("webutil: " + bodyTooLargeTxt)
+
+	// ErrUnsupportedContentType is used when request "Content-Type" header is not allowed
+	ErrUnsupportedContentType = errors.New("webutil: " + unsupportedContentTypeTxt)
+
+	// ErrInvalidMultipart is used when request multipart body can't be parsed
+	ErrInvalidMultipart = errors.New("webutil: " + invalidMultipartTxt)
+
+	// ErrUnknownCurrency is used when currency code is not within CurrencyDecimalPlaces
+	ErrUnknownCurrency = errors.New("webutil: unknown currency")
+
+	// ErrCurrencyMismatch is used for arithmetic between amounts of different currencies
+	ErrCurrencyMismatch = errors.New("webutil: currency mismatch")
+
+	// ErrNonexistentTime is used when wall clock time is skipped by daylight saving time
+	ErrNonexistentTime = errors.New("webutil: nonexistent time")
+
+	// ErrAmbiguousTime is used when wall clock time occurs twice due to daylight saving time
+	ErrAmbiguousTime = errors.New("webutil: ambiguous time")
+
+	// ErrEmailTemplateNotFound is used when rendering email template that was not loaded
+	ErrEmailTemplateNotFound = errors.New("webutil: email template not found")
+
+	// ErrInvalidToken is used when action token is malformed, has an invalid
+	// signature or was created for a different purpose
+	ErrInvalidToken = errors.New("webutil: invalid token")
+
+	// ErrTokenExpired is used when action token is past its expiry
+	ErrTokenExpired = errors.New("webutil: token expired")
+
+	// ErrTokenUsed is used when action token nonce was already consumed
+	ErrTokenUsed = errors.New("webutil: token already used")
+
+	// ErrMigrationVersionNotFound is used when migrating to a version that was not loaded
+	ErrMigrationVersionNotFound = errors.New("webutil: migration version not found")
+
+	// ErrMigrationLock is used when advisory lock could not be acquired for migrations
+	ErrMigrationLock = errors.New("webutil: could not acquire migration lock")
 )
 
 //////////////////////////////////////////////////////////////////
@@ -50,6 +90,10 @@ var (
 
 	// USDCurrencyRegex represents usd currency format to validate for form
 	USDCurrencyRegex = regexp.MustCompile(`^[0-9]+.[0-9]{2}$`)
+
+	// MigrationFileRegex is regex expression used to parse version, name and
+	// direction from migration file names such as "0001_create_user.up.sql"
+	MigrationFileRegex = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
 )
 
 //////////////////////////////////////////////////////////////////
@@ -58,7 +102,7 @@ var (
 
 var (
 	// RequiredRule makes field required and does NOT allow just spaces
-	RequiredRule = &validateRequiredRule{err: errors.New(REQUIRED_TXT)}
+	RequiredRule = &validateRequiredRule{err: NewMessageError(REQUIRED_CODE, nil)}
 )
 
 //////////////////////////////////////////////////////////////////
@@ -0,0 +1,433 @@
+package webutiltest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"text/template"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/TravisS25/webutil/webutil"
+)
+
+// FixtureConfig is config struct used in LoadFixtures
+type FixtureConfig struct {
+	// BindVar is the bind var used when inserting fixture rows
+	BindVar int
+
+	// DBType should be one of webutil.POSTGRES_DRIVER, webutil.MYSQL_DRIVER
+	// or webutil.SQLITE_DRIVER and when set, foreign keys of fixture tables
+	// are read from the database schema to order tables, along with any
+	// tables in Dependencies
+	//
+	// Default: "" (only Dependencies is used)
+	DBType string
+
+	// Dependencies is map of table to the tables it references
+	// so referenced tables are inserted first and deleted last
+	//
+	// IMPORTANT: Without DBType, order comes only from Dependencies and
+	// tables not in it are inserted in the order they appear within
+	// fixture files, so every foreign key must be declared
+	Dependencies map[string][]string
+
+	// TemplateFuncs are extra funcs that can be used within
+	// fixture files along with the default "uuid", "now", "daysAgo",
+	// "daysFromNow", "hoursAgo" and "hoursFromNow" funcs
+	TemplateFuncs template.FuncMap
+}
+
+// Fixtures holds tables and rows loaded from fixture files
+type Fixtures struct {
+	db     webutil.Database
+	config FixtureConfig
+	tables []fixtureTable
+	uuids  map[string]string
+	now    time.Time
+}
+
+type fixtureTable struct {
+	name string
+	rows []fixtureRow
+}
+
+type fixtureRow struct {
+	columns []string
+	values  []any
+}
+
+// LoadFixtures reads yaml or json fixture files from fsys keyed by table
+// with a list of rows for each table, e.g.
+//
+//	user:
+//	  - id: '{{ uuid "admin" }}'
+//	    email: admin@example.com
+//	    created_at: '{{ daysAgo 7 }}'
+//
+// Files are rendered as text/template before being parsed so values such as
+// uuids and relative dates can be generated.  Calling "uuid" with the same
+// key returns the same uuid across all files so rows can reference each other
+//
+// Tables are ordered by foreign keys read from the schema when DBType is
+// set, otherwise only by Dependencies, see FixtureConfig
+func LoadFixtures(db webutil.Database, fsys fs.FS, config FixtureConfig, filenames ...string) (*Fixtures, error) {
+	f := &Fixtures{
+		db:     db,
+		config: config,
+		uuids:  make(map[string]string),
+		now:    time.Now().UTC(),
+	}
+
+	tableMap := make(map[string]*fixtureTable)
+	tableOrder := make([]string, 0)
+
+	for _, filename := range filenames {
+		content, err := fs.ReadFile(fsys, filename)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		tmpl, err := template.New(filename).Funcs(f.templateFuncs()).Parse(string(content))
+		if err != nil {
+			return nil, errors.Wrapf(err, "webutiltest: invalid fixture template %q", filename)
+		}
+
+		var buf bytes.Buffer
+
+		if err = tmpl.Execute(&buf, nil); err != nil {
+			return nil, errors.Wrapf(err, "webutiltest: could not render fixture %q", filename)
+		}
+
+		// yaml is a superset of json so both file types can be
+		// decoded into a node which keeps the order of keys
+		var doc yaml.Node
+
+		if err = yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			return nil, errors.Wrapf(err, "webutiltest: invalid fixture file %q", filename)
+		}
+
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		root := doc.Content[0]
+
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("webutiltest: fixture file %q must be map of table to rows", filename)
+		}
+
+		for i := 0; i < len(root.Content); i += 2 {
+			tableName := root.Content[i].Value
+
+			table, ok := tableMap[tableName]
+			if !ok {
+				table = &fixtureTable{name: tableName}
+				tableMap[tableName] = table
+				tableOrder = append(tableOrder, tableName)
+			}
+
+			rows, err := decodeFixtureRows(root.Content[i+1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "webutiltest: invalid rows for table %q in %q", tableName, filename)
+			}
+
+			table.rows = append(table.rows, rows...)
+		}
+	}
+
+	var err error
+
+	dependencies := make(map[string][]string)
+
+	if config.DBType != "" {
+		if dependencies, err = schemaFixtureDependencies(context.Background(), db, config.DBType, tableOrder); err != nil {
+			return nil, err
+		}
+	}
+
+	for table, deps := range config.Dependencies {
+		dependencies[table] = append(dependencies[table], deps...)
+	}
+
+	sortedTables, err := sortFixtureTables(tableOrder, dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedTables {
+		if table, ok := tableMap[name]; ok {
+			f.tables = append(f.tables, *table)
+		}
+	}
+
+	return f, nil
+}
+
+// UUID returns the uuid generated for key within fixture files
+func (f *Fixtures) UUID(key string) string {
+	return f.uuids[key]
+}
+
+// Tables returns names of tables loaded in insert order
+func (f *Fixtures) Tables() []string {
+	names := make([]string, 0, len(f.tables))
+
+	for _, table := range f.tables {
+		names = append(names, table.name)
+	}
+
+	return names
+}
+
+// Insert inserts all fixture rows within a transaction, parent
+// tables first
+func (f *Fixtures) Insert(ctx context.Context) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for _, table := range f.tables {
+		for _, row := range table.rows {
+			query, args, err := sq.Insert(table.name).Columns(row.columns...).Values(row.values...).ToSql()
+			if err != nil {
+				tx.Rollback()
+				return errors.WithStack(err)
+			}
+
+			if _, err = tx.ExecContext(ctx, webutil.Rebind(f.config.BindVar, query), args...); err != nil {
+				tx.Rollback()
+				return errors.Wrapf(err, "webutiltest: could not insert fixture into %q", table.name)
+			}
+		}
+	}
+
+	return errors.WithStack(tx.Commit())
+}
+
+// Truncate deletes all rows from fixture tables within a
+// transaction, child tables first
+func (f *Fixtures) Truncate(ctx context.Context) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	for i := len(f.tables) - 1; i >= 0; i-- {
+		if _, err = tx.ExecContext(ctx, "DELETE FROM "+f.tables[i].name); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "webutiltest: could not truncate %q", f.tables[i].name)
+		}
+	}
+
+	return errors.WithStack(tx.Commit())
+}
+
+// Reset truncates fixture tables and then inserts fixture rows again
+func (f *Fixtures) Reset(ctx context.Context) error {
+	if err := f.Truncate(ctx); err != nil {
+		return err
+	}
+
+	return f.Insert(ctx)
+}
+
+func (f *Fixtures) templateFuncs() template.FuncMap {
+	formatTime := func(t time.Time) string {
+		return t.Format(webutil.DATE_TIME_LAYOUT)
+	}
+
+	funcs := template.FuncMap{
+		"uuid": func(key string) string {
+			if _, ok := f.uuids[key]; !ok {
+				f.uuids[key] = webutil.NewV7UUIDString()
+			}
+
+			return f.uuids[key]
+		},
+		"now": func() string {
+			return formatTime(f.now)
+		},
+		"daysAgo": func(days int) string {
+			return formatTime(f.now.AddDate(0, 0, -days))
+		},
+		"daysFromNow": func(days int) string {
+			return formatTime(f.now.AddDate(0, 0, days))
+		},
+		"hoursAgo": func(hours int) string {
+			return formatTime(f.now.Add(-time.Hour * time.Duration(hours)))
+		},
+		"hoursFromNow": func(hours int) string {
+			return formatTime(f.now.Add(time.Hour * time.Duration(hours)))
+		},
+	}
+
+	for k, v := range f.config.TemplateFuncs {
+		funcs[k] = v
+	}
+
+	return funcs
+}
+
+func decodeFixtureRows(node *yaml.Node) ([]fixtureRow, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, errors.New("rows must be a list")
+	}
+
+	rows := make([]fixtureRow, 0, len(node.Content))
+
+	for _, rowNode := range node.Content {
+		if rowNode.Kind != yaml.MappingNode {
+			return nil, errors.New("row must be a map of column to value")
+		}
+
+		row := fixtureRow{}
+
+		for i := 0; i < len(rowNode.Content); i += 2 {
+			var val any
+
+			if err := rowNode.Content[i+1].Decode(&val); err != nil {
+				return nil, err
+			}
+
+			// Nested values are stored as json to allow
+			// inserting into json columns
+			switch val.(type) {
+			case map[string]any, []any:
+				jsonBytes, err := json.Marshal(val)
+				if err != nil {
+					return nil, err
+				}
+
+				val = string(jsonBytes)
+			}
+
+			row.columns = append(row.columns, rowNode.Content[i].Value)
+			row.values = append(row.values, val)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// schemaFixtureDependencies returns map of table to the tables it
+// references through foreign keys within database schema
+func schemaFixtureDependencies(ctx context.Context, db webutil.Database, dbType string, tables []string) (map[string][]string, error) {
+	var queries []string
+	var args [][]any
+
+	switch dbType {
+	case webutil.POSTGRES_DRIVER:
+		queries = []string{
+			"SELECT tc.table_name, ccu.table_name " +
+				"FROM information_schema.table_constraints tc " +
+				"JOIN information_schema.constraint_column_usage ccu " +
+				"ON ccu.constraint_name = tc.constraint_name AND ccu.constraint_schema = tc.constraint_schema " +
+				"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema()",
+		}
+		args = [][]any{nil}
+	case webutil.MYSQL_DRIVER:
+		queries = []string{
+			"SELECT table_name, referenced_table_name " +
+				"FROM information_schema.key_column_usage " +
+				"WHERE referenced_table_name IS NOT NULL AND table_schema = DATABASE()",
+		}
+		args = [][]any{nil}
+	case webutil.SQLITE_DRIVER:
+		// Foreign keys can only be listed per table within sqlite
+		for _, table := range tables {
+			queries = append(queries, `SELECT ?, "table" FROM pragma_foreign_key_list(?)`)
+			args = append(args, []any{table, table})
+		}
+	default:
+		return nil, fmt.Errorf("webutiltest: unsupported fixture db type %q", dbType)
+	}
+
+	dependencies := make(map[string][]string)
+
+	for i, query := range queries {
+		rows, err := db.QueryContext(ctx, query, args[i]...)
+		if err != nil {
+			return nil, errors.Wrap(err, "webutiltest: could not read foreign keys")
+		}
+
+		for rows.Next() {
+			var table, ref string
+
+			if err = rows.Scan(&table, &ref); err != nil {
+				rows.Close()
+				return nil, errors.WithStack(err)
+			}
+
+			// Self references don't affect order of tables
+			if table != ref {
+				dependencies[table] = append(dependencies[table], ref)
+			}
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// sortFixtureTables orders tables so every table comes after the tables
+// it depends on while otherwise keeping the order tables were found in
+func sortFixtureTables(tables []string, dependencies map[string][]string) ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	sorted := make([]string, 0, len(tables))
+
+	var visit func(table string) error
+
+	visit = func(table string) error {
+		switch state[table] {
+		case visiting:
+			return fmt.Errorf("webutiltest: circular fixture dependency on table %q", table)
+		case visited:
+			return nil
+		}
+
+		state[table] = visiting
+
+		deps := append([]string(nil), dependencies[table]...)
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[table] = visited
+		sorted = append(sorted, table)
+		return nil
+	}
+
+	for _, table := range tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -0,0 +1,132 @@
+package webutiltest
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/TravisS25/webutil/webutil"
+)
+
+func TestFixtures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"phone.json": {Data: []byte(`{"phone": [{"user_id": "{{ uuid "admin" }}", "number": "(555)-555-5555"}]}`)},
+		"user.yaml": {Data: []byte(`
+user:
+  - id: '{{ uuid "admin" }}'
+    email: admin@example.com
+    settings:
+      theme: dark
+    created_at: '{{ daysAgo 7 }}'
+`)},
+	}
+
+	fixtures, err := LoadFixtures(
+		db,
+		fsys,
+		FixtureConfig{
+			BindVar: webutil.DOLLAR_SQL_BIND_VAR,
+			Dependencies: map[string][]string{
+				"phone": {"user"},
+			},
+		},
+		"phone.json",
+		"user.yaml",
+	)
+	if err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+
+	if tables := fixtures.Tables(); len(tables) != 2 || tables[0] != "user" || tables[1] != "phone" {
+		t.Fatalf("tables should be [user phone]; got %v\n", tables)
+	}
+
+	adminID := fixtures.UUID("admin")
+
+	if adminID == "" {
+		t.Fatalf("should have generated uuid for admin\n")
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO user (id,email,settings,created_at) VALUES ($1,$2,$3,$4)")).
+		WithArgs(adminID, "admin@example.com", `{"theme":"dark"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO phone (user_id,number) VALUES ($1,$2)")).
+		WithArgs(adminID, "(555)-555-5555").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err = fixtures.Insert(context.Background()); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM phone").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM user").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err = fixtures.Truncate(context.Background()); err != nil {
+		t.Errorf("should not have error; got %s\n", err.Error())
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	if _, err = LoadFixtures(db, fsys, FixtureConfig{
+		Dependencies: map[string][]string{
+			"phone": {"user"},
+			"user":  {"phone"},
+		},
+	}, "phone.json", "user.yaml"); err == nil {
+		t.Errorf("should have circular dependency error\n")
+	}
+
+	// ---------------------------------------------------------------------
+
+	// Foreign keys are read from schema when DBType is set
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.table_constraints tc")).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "table_name"}).
+			AddRow("phone", "user").
+			AddRow("user", "user"))
+
+	if fixtures, err = LoadFixtures(db, fsys, FixtureConfig{DBType: webutil.POSTGRES_DRIVER}, "phone.json", "user.yaml"); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if tables := fixtures.Tables(); len(tables) != 2 || tables[0] != "user" || tables[1] != "phone" {
+		t.Errorf("tables should be [user phone]; got %v\n", tables)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM pragma_foreign_key_list(?)`)).
+		WithArgs("phone", "phone").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "table"}).AddRow("phone", "user"))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM pragma_foreign_key_list(?)`)).
+		WithArgs("user", "user").
+		WillReturnRows(sqlmock.NewRows([]string{"table", "table"}))
+
+	if fixtures, err = LoadFixtures(db, fsys, FixtureConfig{DBType: webutil.SQLITE_DRIVER}, "phone.json", "user.yaml"); err != nil {
+		t.Fatalf("should not have error; got %s\n", err.Error())
+	}
+	if tables := fixtures.Tables(); len(tables) != 2 || tables[0] != "user" || tables[1] != "phone" {
+		t.Errorf("tables should be [user phone]; got %v\n", tables)
+	}
+
+	if _, err = LoadFixtures(db, fsys, FixtureConfig{DBType: "invalid"}, "phone.json"); err == nil {
+		t.Errorf("should have unsupported db type error\n")
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("expectations not met: %s\n", err.Error())
+	}
+}
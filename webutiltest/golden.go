@@ -0,0 +1,141 @@
+package webutiltest
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/TravisS25/webutil/webutil"
+)
+
+// UPDATE_GOLDEN_ENV is env var that when set to a non empty value, ex.
+// "WEBUTIL_UPDATE_GOLDEN=1 go test ./...", will overwrite golden files
+// with current output instead of comparing against them
+const UPDATE_GOLDEN_ENV = "WEBUTIL_UPDATE_GOLDEN"
+
+// UpdateGolden can be set to true within tests to overwrite golden
+// files the same as setting UPDATE_GOLDEN_ENV or passing -update,
+// ex. "go test ./... -update"
+var UpdateGolden bool
+
+func init() {
+	// -update is commonly registered by other golden file packages
+	// so it's only registered when not already defined, where
+	// isUpdateGolden reads whichever flag was registered
+	if flag.Lookup("update") == nil {
+		flag.Bool("update", false, "overwrite golden files with current output")
+	}
+}
+
+// isUpdateGolden returns whether golden files should be overwritten
+func isUpdateGolden() bool {
+	if UpdateGolden || os.Getenv(UPDATE_GOLDEN_ENV) != "" {
+		return true
+	}
+
+	if f := flag.Lookup("update"); f != nil {
+		update, _ := strconv.ParseBool(f.Value.String())
+		return update
+	}
+
+	return false
+}
+
+// RenderQueryBuilder applies request to builder through webutil.GetQueryBuilder
+// and returns final sql and args after being rebound with bindVar
+//
+// If GetQueryBuilder returns error, the error is rendered instead so
+// invalid filters can be locked down as well
+func RenderQueryBuilder(
+	req *http.Request,
+	builder sq.SelectBuilder,
+	dbFields webutil.DbFields,
+	cfg webutil.QueryConfig,
+	bindVar int,
+) (string, error) {
+	var sb strings.Builder
+
+	b, err := webutil.GetQueryBuilder(req, builder, dbFields, cfg)
+	if err != nil {
+		sb.WriteString("-- error\n")
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+		return sb.String(), nil
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	if query, args, err = webutil.InQueryRebind(bindVar, query, args...); err != nil {
+		return "", err
+	}
+
+	if args == nil {
+		args = []any{}
+	}
+
+	argBytes, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	sb.WriteString("-- query\n")
+	sb.WriteString(query)
+	sb.WriteString("\n-- args\n")
+	sb.Write(argBytes)
+	sb.WriteString("\n")
+
+	return sb.String(), nil
+}
+
+// ValidateGoldenSQL renders query builder output with RenderQueryBuilder and
+// compares it against goldenFile, usually found within "testdata" dir
+//
+// When -update is passed, UpdateGolden is true or UPDATE_GOLDEN_ENV
+// is set, goldenFile is written with the current output instead
+func ValidateGoldenSQL(
+	t TestLog,
+	goldenFile string,
+	req *http.Request,
+	builder sq.SelectBuilder,
+	dbFields webutil.DbFields,
+	cfg webutil.QueryConfig,
+	bindVar int,
+) {
+	t.Helper()
+
+	actual, err := RenderQueryBuilder(req, builder, dbFields, cfg, bindVar)
+	if err != nil {
+		t.Errorf("webutiltest: could not render query builder: %s", err)
+		return
+	}
+
+	if isUpdateGolden() {
+		if err = os.MkdirAll(filepath.Dir(goldenFile), 0755); err != nil {
+			t.Errorf("webutiltest: could not create golden dir: %s", err)
+			return
+		}
+		if err = os.WriteFile(goldenFile, []byte(actual), 0644); err != nil {
+			t.Errorf("webutiltest: could not write golden file: %s", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Errorf("webutiltest: could not read golden file %q, set %s to create it: %s", goldenFile, UPDATE_GOLDEN_ENV, err)
+		return
+	}
+
+	if string(expected) != actual {
+		t.Errorf("webutiltest: output does not match golden file %q\n\nexpected:\n%s\ngot:\n%s", goldenFile, expected, actual)
+	}
+}
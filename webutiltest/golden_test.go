@@ -0,0 +1,85 @@
+package webutiltest
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/TravisS25/webutil/webutil"
+)
+
+func TestValidateGoldenSQL(t *testing.T) {
+	cfg := webutil.QueryConfig{
+		FilterParam: "filters",
+		OrderParam:  "sorts",
+		Limit:       100,
+	}
+	dbFields := webutil.DbFields{
+		"name": webutil.FieldConfig{
+			DBField: "user.name",
+			OperationCfg: webutil.OperationConfig{
+				CanFilterBy: true,
+				CanSortBy:   true,
+			},
+		},
+	}
+	builder := sq.Select("user.id", "user.name").From("user")
+
+	filterBytes, _ := json.Marshal([]webutil.Filter{
+		{Field: "name", Operator: "eq", Value: "foo"},
+	})
+	sortBytes, _ := json.Marshal([]webutil.Sort{
+		{Field: "name", Dir: "desc"},
+	})
+
+	urlVals := url.Values{}
+	urlVals.Add(cfg.FilterParam, string(filterBytes))
+	urlVals.Add(cfg.OrderParam, string(sortBytes))
+
+	req := httptest.NewRequest(http.MethodGet, "/url?"+urlVals.Encode(), nil)
+
+	ValidateGoldenSQL(t, "testdata/query_builder.golden", req, builder, dbFields, cfg, webutil.DOLLAR_SQL_BIND_VAR)
+
+	// ---------------------------------------------------------------------
+
+	urlVals = url.Values{}
+	urlVals.Add(cfg.FilterParam, `[{"field": "invalid", "operator": "eq", "value": "foo"}]`)
+
+	req = httptest.NewRequest(http.MethodGet, "/url?"+urlVals.Encode(), nil)
+
+	ValidateGoldenSQL(t, "testdata/query_builder_error.golden", req, builder, dbFields, cfg, webutil.DOLLAR_SQL_BIND_VAR)
+
+	// ---------------------------------------------------------------------
+
+	goldenFile := filepath.Join(t.TempDir(), "testdata", "update.golden")
+	t.Setenv(UPDATE_GOLDEN_ENV, "1")
+
+	ValidateGoldenSQL(t, goldenFile, req, builder, dbFields, cfg, webutil.DOLLAR_SQL_BIND_VAR)
+
+	if _, err := os.Stat(goldenFile); err != nil {
+		t.Errorf("should have written golden file; got %s\n", err.Error())
+	}
+
+	// ---------------------------------------------------------------------
+
+	goldenFile = filepath.Join(t.TempDir(), "testdata", "flag.golden")
+	t.Setenv(UPDATE_GOLDEN_ENV, "")
+
+	if err := flag.Set("update", "true"); err != nil {
+		t.Fatalf("should have -update flag; got %s\n", err.Error())
+	}
+	defer flag.Set("update", "false")
+
+	ValidateGoldenSQL(t, goldenFile, req, builder, dbFields, cfg, webutil.DOLLAR_SQL_BIND_VAR)
+
+	if _, err := os.Stat(goldenFile); err != nil {
+		t.Errorf("should have written golden file with -update; got %s\n", err.Error())
+	}
+}
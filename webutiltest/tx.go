@@ -0,0 +1,35 @@
+package webutiltest
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+
+	"github.com/TravisS25/webutil/webutil"
+)
+
+// NewTestTx begins a transaction on db that is rolled back automatically
+// once the test and its subtests finish
+//
+// The returned transaction should be passed wherever a qrm.Queryable is
+// used, e.g. QuerySelectBuilder or NewFormValidation, so nothing written
+// during the test is committed and tests can run in parallel against
+// one database
+func NewTestTx(t TestCleanupLog, db webutil.Database, opts *sql.TxOptions) *sql.Tx {
+	t.Helper()
+
+	tx, err := db.BeginTx(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("webutiltest: could not begin test transaction: %s", err)
+		return nil
+	}
+
+	t.Cleanup(func() {
+		if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("webutiltest: could not rollback test transaction: %s", err)
+		}
+	})
+
+	return tx
+}
@@ -0,0 +1,32 @@
+package webutiltest
+
+import (
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestNewTestTx(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("err: %s\n", err.Error())
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO user").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	t.Run("insert", func(t *testing.T) {
+		tx := NewTestTx(t, db, nil)
+
+		if _, err := tx.ExecContext(context.Background(), "INSERT INTO user (name) VALUES ('foo')"); err != nil {
+			t.Errorf("should not have error; got %s\n", err.Error())
+		}
+	})
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("transaction should have been rolled back: %s\n", err.Error())
+	}
+}
@@ -11,3 +11,10 @@ type TestLog interface {
 	Fatalf(string, ...any)
 	Helper()
 }
+
+// TestCleanupLog is TestLog that can also register cleanup funcs
+// to run once a test finishes
+type TestCleanupLog interface {
+	TestLog
+	Cleanup(func())
+}